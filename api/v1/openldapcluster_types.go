@@ -4,16 +4,81 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
 	"github.com/qwp0905/openldap-operator/pkg/utils"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 const (
-	ConditionInitialized = "Initialized"
-	ConditionReady       = "Ready"
-	ConditionElected     = "Elected"
+	// ClusterFinalizer cleans up the volume claims left by the statefulset
+	// once the cluster is deleted.
+	ClusterFinalizer = "openldap.kwonjin.click/cleanup"
+
+	RetainedVolumeLabel    = "openldap.kwonjin.click/retained"
+	RetainedFromAnnotation = "openldap.kwonjin.click/retained-from"
+)
+
+const (
+	ConditionInitialized        = "Initialized"
+	ConditionReady              = "Ready"
+	ConditionElected            = "Elected"
+	ConditionProgressing        = "Progressing"
+	ConditionDegraded           = "Degraded"
+	ConditionReplicationHealthy = "ReplicationHealthy"
+	ConditionConfigReplicated   = "ConfigReplicated"
+	ConditionVolumeExpansion    = "VolumeExpansion"
+	ConditionStorageMigration   = "StorageMigration"
+	ConditionTlsReady           = "TLSReady"
+)
+
+const (
+	ReasonClusterCreated       = "ClusterCreated"
+	ReasonClusterReady         = "ClusterReady"
+	ReasonMasterNotElected     = "MasterNotElected"
+	ReasonMasterUnhealthy      = "MasterUnhealthy"
+	ReasonMasterNodeCordoned   = "MasterNodeCordoned"
+	ReasonPreferredMaster      = "PreferredMasterReady"
+	ReasonProviderActivated    = "ProviderActivated"
+	ReasonReplicationSwitched  = "ReplicationSwitched"
+	ReasonReplicaOfSource      = "ReplicaOfSource"
+	ReasonMasterNotElectable   = "MasterNotElectable"
+	ReasonMasterScaledDown     = "MasterScaledDown"
+	ReasonNoHealthyPod         = "NoHealthyPod"
+	ReasonPromotionJobCreated  = "PromotionJobCreated"
+	ReasonPromotionJobFailed   = "PromotionJobFailed"
+	ReasonReplicasReady        = "ReplicasReady"
+	ReasonReplicasNotReady     = "ReplicasNotReady"
+	ReasonRollingUpdate        = "RollingUpdate"
+	ReasonElectionInProgress   = "ElectionInProgress"
+	ReasonReconciled           = "Reconciled"
+	ReasonReplicationInSync    = "ReplicationInSync"
+	ReasonReplicationLagging   = "ReplicationLagging"
+	ReasonReplicationUnknown   = "ReplicationNotMonitored"
+	ReasonConfigConverged      = "ConfigConverged"
+	ReasonConfigNotConverged   = "ConfigNotConverged"
+	ReasonVolumeExpanding      = "VolumeExpanding"
+	ReasonVolumeExpanded       = "VolumeExpanded"
+	ReasonNotExpandable        = "StorageClassNotExpandable"
+	ReasonTemplateImmutable    = "VolumeClaimTemplateImmutable"
+	ReasonStorageMigrating     = "StorageMigrating"
+	ReasonStorageMigrated      = "StorageMigrated"
+	ReasonSingleReplica        = "SingleReplica"
+	ReasonMasterOnOldStorage   = "MasterOnOldStorage"
+	ReasonMonitorDisabled      = "OperatorMonitorDisabled"
+	ReasonTlsSecretFound       = "SecretFound"
+	ReasonTlsSecretNotFound    = "SecretNotFound"
+	ReasonTlsSecretKeyNotFound = "SecretKeyNotFound"
+	ReasonTlsSanMissing        = "CertificateSanMissing"
 )
 
 // OpenldapClusterSpec defines the desired state of OpenldapCluster
@@ -36,6 +101,30 @@ type OpenldapClusterSpec struct {
 
 	//+optional
 	Monitor *MonitorConfig `json:"monitor,omitempty"`
+
+	//+optional
+	Services *ServicesConfig `json:"services,omitempty"`
+
+	//+optional
+	NetworkPolicy *NetworkPolicyConfig `json:"networkPolicy,omitempty"`
+
+	//+optional
+	PodDisruptionBudget *PodDisruptionBudgetConfig `json:"podDisruptionBudget,omitempty"`
+
+	//+optional
+	Election *ElectionConfig `json:"election,omitempty"`
+
+	//+optional
+	Topology *TopologyConfig `json:"topology,omitempty"`
+
+	//+optional
+	Replica *ReplicaConfig `json:"replica,omitempty"`
+
+	//+optional
+	Replication *ReplicationConfig `json:"replication,omitempty"`
+
+	//+optional
+	Autoscaling *AutoscalingConfig `json:"autoscaling,omitempty"`
 }
 
 type ClusterPodTemplate struct {
@@ -70,6 +159,10 @@ type ClusterPodTemplate struct {
 	//+optional
 	PriorityClassName string `json:"priorityClassName,omitempty"`
 
+	// Spread pods across zones by default, only defaulted on creation
+	//+optional
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+
 	//+optional
 	Ports *PortConfig `json:"ports,omitempty"`
 }
@@ -87,6 +180,16 @@ type PortConfig struct {
 type StorageConfig struct {
 	//+kubebuilder:validation:Required
 	VolumeClaimTemplate corev1.PersistentVolumeClaimSpec `json:"volumeClaimTemplate,omitempty"`
+
+	// whenDeleted Delete removes the volume claims with the cluster, Retain
+	// keeps them labeled as retained.
+	//+optional
+	PersistentVolumeClaimRetentionPolicy *appsv1.StatefulSetPersistentVolumeClaimRetentionPolicy `json:"persistentVolumeClaimRetentionPolicy,omitempty"`
+
+	// adoptRetainedVolumes allows the cluster to start on the volume claims
+	// retained from a deleted cluster with the same name.
+	//+optional
+	AdoptRetainedVolumes bool `json:"adoptRetainedVolumes,omitempty"`
 }
 
 type OpenldapConfig struct {
@@ -137,12 +240,26 @@ type TlsConfig struct {
 
 	//+optional
 	KeyFile string `json:"keyFile,omitempty"`
+
+	// Require StartTLS (olcSecurity tls=1) for binds on the plain ldap port
+	//+kubebuilder:default:=false
+	RequireTls bool `json:"requireTls,omitempty"`
+
+	// Drop the plain ldap port from pods and services and serve only ldaps
+	//+kubebuilder:default:=false
+	LdapsOnly bool `json:"ldapsOnly,omitempty"`
 }
 
 type MonitorConfig struct {
 	//+kubebuilder:default:=false
 	Enabled bool `json:"enabled,omitempty"`
 
+	// Exporter runs a metrics sidecar in every pod, Operator queries cn=Monitor
+	// of each pod from the operator and exposes metrics on its own endpoint
+	//+kubebuilder:default:=Exporter
+	//+kubebuilder:validation:Enum:=Exporter;Operator
+	Mode MonitorMode `json:"mode,omitempty"`
+
 	//+optional
 	Labels map[string]string `json:"labels,omitempty"`
 
@@ -151,19 +268,496 @@ type MonitorConfig struct {
 
 	//+kubebuilder:default:="10s"
 	ScrapeTimeout string `json:"scrapeTimeout,omitempty"`
+
+	//+optional
+	Exporter *ExporterConfig `json:"exporter,omitempty"`
+
+	//+optional
+	Relabelings []*monitoringv1.RelabelConfig `json:"relabelings,omitempty"`
+
+	//+optional
+	MetricRelabelings []*monitoringv1.RelabelConfig `json:"metricRelabelings,omitempty"`
+
+	//+optional
+	Rules *MonitorRulesConfig `json:"rules,omitempty"`
+}
+
+type ExporterConfig struct {
+	//+kubebuilder:default:="qwp1216/openldap-exporter:0.0.4"
+	Image string `json:"image,omitempty"`
+
+	//+optional
+	ImagePullPolicy corev1.PullPolicy `json:"imagePullPolicy,omitempty"`
+
+	//+optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	//+kubebuilder:default:=9142
+	//+kubebuilder:validation:Minimum:=1
+	Port int32 `json:"port,omitempty"`
+
+	//+optional
+	Args []string `json:"args,omitempty"`
+
+	//+optional
+	Env []corev1.EnvVar `json:"env,omitempty"`
+
+	//+optional
+	SecurityContext *corev1.SecurityContext `json:"securityContext,omitempty"`
+
+	// Dedicated bind dn for the exporter, admin dn is used if empty
+	//+optional
+	BindDn string `json:"bindDn,omitempty"`
+
+	//+optional
+	BindPassword *corev1.SecretKeySelector `json:"bindPassword,omitempty"`
+}
+
+type MonitorMode string
+
+const (
+	MonitorModeExporter MonitorMode = "Exporter"
+	MonitorModeOperator MonitorMode = "Operator"
+)
+
+type MonitorRulesConfig struct {
+	//+kubebuilder:default:=false
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Labels added to the PrometheusRule and to every alert
+	//+optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	//+kubebuilder:default:="5m"
+	For string `json:"for,omitempty"`
+
+	//+kubebuilder:default:=60
+	//+kubebuilder:validation:Minimum:=1
+	ReplicationLagSeconds int32 `json:"replicationLagSeconds,omitempty"`
+
+	//+kubebuilder:default:=1000
+	//+kubebuilder:validation:Minimum:=1
+	MaxConnections int32 `json:"maxConnections,omitempty"`
+
+	//+optional
+	CertExpiry *CertExpiryRuleConfig `json:"certExpiry,omitempty"`
+}
+
+type CertExpiryRuleConfig struct {
+	// Alert on the expiry of the tls certificate. The expiry is not exported
+	// by openldap, it is read from an exporter watching the tls secret such as
+	// x509-certificate-exporter
+	//+kubebuilder:default:=false
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Metric of the expiry as unix timestamp, labeled by secret_namespace and
+	// secret_name of the tls secret
+	//+kubebuilder:default:="x509_cert_not_after"
+	Metric string `json:"metric,omitempty"`
+
+	//+kubebuilder:default:=14
+	//+kubebuilder:validation:Minimum:=1
+	Days int32 `json:"days,omitempty"`
+}
+
+type ServicesConfig struct {
+	//+optional
+	Read *ReadServiceTemplate `json:"read,omitempty"`
+
+	//+optional
+	Write *ServiceTemplate `json:"write,omitempty"`
+
+	//+optional
+	Metrics *ServiceTemplate `json:"metrics,omitempty"`
+}
+
+type ReadServiceTemplate struct {
+	ServiceTemplate `json:",inline"`
+
+	// Route reads only to slave pods, falling back to the master when no slave is ready
+	//+kubebuilder:default:=false
+	ExcludeMaster bool `json:"excludeMaster,omitempty"`
+}
+
+type ServiceTemplate struct {
+	//+kubebuilder:default:=ClusterIP
+	//+kubebuilder:validation:Enum:=ClusterIP;NodePort;LoadBalancer
+	Type corev1.ServiceType `json:"type,omitempty"`
+
+	//+optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	//+optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	//+optional
+	LoadBalancerClass *string `json:"loadBalancerClass,omitempty"`
+
+	//+optional
+	LoadBalancerSourceRanges []string `json:"loadBalancerSourceRanges,omitempty"`
+
+	//+optional
+	ExternalTrafficPolicy corev1.ServiceExternalTrafficPolicyType `json:"externalTrafficPolicy,omitempty"`
+
+	// Node ports keyed by service port name (ldap, ldaps, metrics)
+	//+optional
+	NodePorts map[string]int32 `json:"nodePorts,omitempty"`
+}
+
+type NetworkPolicyConfig struct {
+	//+kubebuilder:default:=false
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Peers allowed to connect to ldap and ldaps ports, pods in the same namespace if empty
+	//+optional
+	From []networkingv1.NetworkPolicyPeer `json:"from,omitempty"`
+
+	// Peers allowed to scrape the metrics port
+	//+optional
+	MetricsFrom []networkingv1.NetworkPolicyPeer `json:"metricsFrom,omitempty"`
+
+	// Namespace of the operator, allowed to connect to every port. Defaults to
+	// the namespace the operator runs in
+	//+optional
+	OperatorNamespace string `json:"operatorNamespace,omitempty"`
+
+	// Additional destinations the pods may connect to, dns and the other pods
+	// of the cluster are always allowed
+	//+optional
+	Egress []networkingv1.NetworkPolicyEgressRule `json:"egress,omitempty"`
+}
+
+type PodDisruptionBudgetConfig struct {
+	//+optional
+	//+kubebuilder:default:=true
+	Enabled bool `json:"enabled"`
+
+	// Only one of minAvailable and maxUnavailable can be set, maxUnavailable 1 if both empty
+	//+optional
+	MinAvailable *intstr.IntOrString `json:"minAvailable,omitempty"`
+
+	//+optional
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+
+	// Switch the master to another pod when the node of the master is cordoned
+	//+optional
+	//+kubebuilder:default:=true
+	SwitchoverOnDrain bool `json:"switchoverOnDrain"`
+}
+
+// ReplicaConfig runs the whole cluster as read-only consumer of an external
+// provider. Disabling it promotes the cluster with a normal master election.
+type ReplicaConfig struct {
+	//+kubebuilder:default:=false
+	Enabled bool `json:"enabled,omitempty"`
+
+	//+optional
+	Source *ReplicaSource `json:"source,omitempty"`
+}
+
+// ReplicaSource is either an external provider or another OpenldapCluster.
+// A cluster in another kubernetes cluster is reached by the url of its exposed
+// write service.
+type ReplicaSource struct {
+	// Url of the external provider, e.g. ldaps://ldap.example.com:636
+	//+optional
+	//+kubebuilder:validation:Pattern:=`^ldaps?://`
+	Url string `json:"url,omitempty"`
+
+	// OpenldapCluster replicated from, its write service is used as provider
+	//+optional
+	Cluster *ClusterReference `json:"cluster,omitempty"`
+
+	BindDn string `json:"bindDn"`
+
+	BindPassword *corev1.SecretKeySelector `json:"bindPassword"`
+
+	// Search base replicated from the provider, root of the cluster if empty
+	//+optional
+	SearchBase string `json:"searchBase,omitempty"`
+
+	// Ca certificate to verify the provider
+	//+optional
+	Ca *corev1.SecretKeySelector `json:"ca,omitempty"`
+}
+
+type ClusterReference struct {
+	Name string `json:"name"`
+
+	// Namespace of the cluster, namespace of the replica if empty
+	//+optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+type ReplicationMode string
+
+const (
+	ReplicationSyncrepl      ReplicationMode = "syncrepl"
+	ReplicationDeltaSyncrepl ReplicationMode = "delta-syncrepl"
+)
+
+// ReplicationConfig lets the operator own the syncrepl configuration of every
+// pod, written to cn=config instead of the replication scripts of the image.
+type ReplicationConfig struct {
+	// Render syncrepl by the operator, configPassword must be provided
+	//+kubebuilder:default:=false
+	Enabled bool `json:"enabled,omitempty"`
+
+	// delta-syncrepl replicates the changes recorded in cn=accesslog
+	//+kubebuilder:default:=syncrepl
+	//+kubebuilder:validation:Enum:=syncrepl;delta-syncrepl
+	Mode ReplicationMode `json:"mode,omitempty"`
+
+	// Interval between synchronizations in dd:hh:mm:ss
+	//+kubebuilder:default:="00:00:05:00"
+	//+kubebuilder:validation:Pattern:=`^[0-9]{2}:[0-9]{2}:[0-9]{2}:[0-9]{2}$`
+	Interval string `json:"interval,omitempty"`
+
+	// Retry schedule as pairs of interval seconds and count, e.g. "60 10 300 +"
+	//+kubebuilder:default:="60 +"
+	Retry string `json:"retry,omitempty"`
+
+	// Seconds to wait for the provider to respond
+	//+kubebuilder:default:=10
+	//+kubebuilder:validation:Minimum:=0
+	Timeout int32 `json:"timeout,omitempty"`
+
+	// TCP keepalive of the connection to the provider in idle:probes:interval
+	//+kubebuilder:default:="240:10:30"
+	//+kubebuilder:validation:Pattern:=`^[0-9]+:[0-9]+:[0-9]+$`
+	Keepalive string `json:"keepalive,omitempty"`
+
+	// Base of the replicated entries, root or search base of the replica source if empty
+	//+optional
+	SearchBase string `json:"searchBase,omitempty"`
+
+	// Filter of the replicated entries
+	//+optional
+	Filter string `json:"filter,omitempty"`
+
+	// Attributes replicated, all attributes if empty
+	//+optional
+	Attrs []string `json:"attrs,omitempty"`
+
+	// Dn of the replicated database in cn=config
+	//+kubebuilder:default:="olcDatabase={2}mdb,cn=config"
+	DatabaseDn string `json:"databaseDn,omitempty"`
+
+	// Replicate cn=config from the master to every other pod, so that schema,
+	// acl, overlay and index changes applied on the master reach the others.
+	// The server id of each pod is its ordinal plus one.
+	//+kubebuilder:default:=false
+	ConfigDatabase bool `json:"configDatabase,omitempty"`
+}
+
+// AutoscalingConfig creates a HorizontalPodAutoscaler scaling the cluster
+// through its scale subresource.
+type AutoscalingConfig struct {
+	//+kubebuilder:default:=false
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Never scale below two pods, so that the master can fail over
+	//+optional
+	//+kubebuilder:default:=true
+	HighAvailability bool `json:"highAvailability"`
+
+	// Two if high availability is requested, one otherwise
+	//+optional
+	//+kubebuilder:validation:Minimum:=1
+	MinReplicas *int32 `json:"minReplicas,omitempty"`
+
+	//+kubebuilder:validation:Minimum:=1
+	MaxReplicas int32 `json:"maxReplicas,omitempty"`
+
+	// Target average cpu utilization of the pods
+	//+optional
+	//+kubebuilder:validation:Minimum:=1
+	TargetCPUUtilizationPercentage *int32 `json:"targetCPUUtilizationPercentage,omitempty"`
+
+	//+optional
+	SearchRate *SearchRateTarget `json:"searchRate,omitempty"`
+
+	//+optional
+	Behavior *autoscalingv2.HorizontalPodAutoscalerBehavior `json:"behavior,omitempty"`
+}
+
+// SearchRateTarget scales on the search rate of the pods scraped from the
+// exporter, served to the HorizontalPodAutoscaler by a custom metrics adapter.
+type SearchRateTarget struct {
+	// Name of the per pod metric in the custom metrics api
+	//+kubebuilder:default:=openldap_search_operations_per_second
+	Metric string `json:"metric,omitempty"`
+
+	// Target average searches per second of a pod
+	AverageValue resource.Quantity `json:"averageValue"`
+}
+
+type TopologyMode string
+
+const (
+	TopologySingleMaster  TopologyMode = "SingleMaster"
+	TopologyMirrorMode    TopologyMode = "MirrorMode"
+	TopologyMultiProvider TopologyMode = "MultiProvider"
+)
+
+type TopologyConfig struct {
+	// SingleMaster promotes a consumer to provider on failover. MirrorMode runs
+	// ordinals 0 and 1 as providers behind the write service in active/standby.
+	// MultiProvider runs every pod as provider behind the write service. The
+	// server id of each provider is its ordinal plus one.
+	//+kubebuilder:default:=SingleMaster
+	//+kubebuilder:validation:Enum:=SingleMaster;MirrorMode;MultiProvider
+	Mode TopologyMode `json:"mode,omitempty"`
+}
+
+type ElectionConfig struct {
+	// Zone preferred for the new master on election, a zone different from
+	// the failed master is preferred if empty
+	//+optional
+	PreferredZone string `json:"preferredZone,omitempty"`
+
+	// Pod with higher priority is elected first, 0 if not listed
+	//+optional
+	Priorities []OrdinalPriority `json:"priorities,omitempty"`
+
+	// Ordinals of pods which never become master
+	//+optional
+	ExcludedOrdinals []int32 `json:"excludedOrdinals,omitempty"`
+
+	// Ordinal of the pod the master is switched back to once it is healthy and
+	// its contextCSN caught up with the master, which is only observed in the
+	// operator monitor mode
+	//+optional
+	//+kubebuilder:validation:Minimum:=0
+	PreferredMaster *int32 `json:"preferredMaster,omitempty"`
+
+	//+optional
+	Fencing *FencingConfig `json:"fencing,omitempty"`
+}
+
+// FencingConfig makes the master hold a lease renewed by the operator. The
+// fencer sidecar makes the database read-only once the lease is lost, and a
+// new master is promoted only after the lease of the old master expired.
+type FencingConfig struct {
+	//+kubebuilder:default:=false
+	Enabled bool `json:"enabled,omitempty"`
+
+	//+optional
+	//+kubebuilder:default:=30
+	//+kubebuilder:validation:Minimum:=10
+	LeaseDurationSeconds int32 `json:"leaseDurationSeconds,omitempty"`
+
+	// Database made read-only when the lease is lost
+	//+optional
+	//+kubebuilder:default:="olcDatabase={2}mdb,cn=config"
+	DatabaseDn string `json:"databaseDn,omitempty"`
+
+	//+optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+type OrdinalPriority struct {
+	//+kubebuilder:validation:Minimum:=0
+	Ordinal int32 `json:"ordinal"`
+
+	Priority int32 `json:"priority"`
+}
+
+// OpenldapClusterStatus defines the observed state of OpenldapCluster
+type OpenldapClusterStatus struct {
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type" protobuf:"bytes,1,rep,name=conditions"`
+
+	CurrentMaster string `json:"currentMaster,omitempty"`
+
+	DesiredMaster string `json:"desiredMaster,omitempty"`
+
+	// Zone of the node the current master is running on
+	//+optional
+	MasterZone string `json:"masterZone,omitempty"`
+
+	//+optional
+	Phase ClusterPhase `json:"phase,omitempty"`
+
+	//+optional
+	Replicas int32 `json:"replicas,omitempty"`
+
+	// Label selector of the pods for the scale subresource
+	//+optional
+	Selector string `json:"selector,omitempty"`
+
+	//+optional
+	ReadyReplicas int32 `json:"readyReplicas,omitempty"`
+
+	// Ready replicas over total replicas, e.g. 2/3
+	//+optional
+	Ready string `json:"ready,omitempty"`
+
+	//+optional
+	Version string `json:"version,omitempty"`
+
+	//+optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	//+optional
+	Replication *ReplicationStatus `json:"replication,omitempty"`
+
+	//+optional
+	ConfigReplication *ConfigReplicationStatus `json:"configReplication,omitempty"`
 }
 
-// OpenldapClusterStatus defines the observed state of OpenldapCluster
-type OpenldapClusterStatus struct {
-	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type" protobuf:"bytes,1,rep,name=conditions"`
+type ConfigReplicationStatus struct {
+	// Pods with the newest cn=config
+	//+optional
+	ConvergedPods []string `json:"convergedPods,omitempty"`
+
+	// Pods behind the newest cn=config or not reachable
+	//+optional
+	PendingPods []string `json:"pendingPods,omitempty"`
+
+	// Last change of cn=config among the pods
+	//+optional
+	LastChangeTime *metav1.Time `json:"lastChangeTime,omitempty"`
+}
+
+type ReplicationStatus struct {
+	// Url of the provider the cluster replicates from
+	//+optional
+	Source string `json:"source,omitempty"`
+
+	// Last change of the source replicated to every pod
+	//+optional
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
 
-	CurrentMaster string `json:"currentMaster,omitempty"`
+	// Seconds the pods are behind the source
+	//+optional
+	LagSeconds *int64 `json:"lagSeconds,omitempty"`
 
-	DesiredMaster string `json:"desiredMaster,omitempty"`
+	// Time the replica cluster was promoted to an independent cluster
+	//+optional
+	PromotedAt *metav1.Time `json:"promotedAt,omitempty"`
 }
 
+type ClusterPhase string
+
+const (
+	PhaseInitializing ClusterPhase = "Initializing"
+	PhaseHealthy      ClusterPhase = "Healthy"
+	PhaseFailingOver  ClusterPhase = "FailingOver"
+	PhaseDegraded     ClusterPhase = "Degraded"
+	PhaseReplica      ClusterPhase = "Replica"
+	PhaseSwitchover   ClusterPhase = "Switchover"
+)
+
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:subresource:scale:specpath=.spec.replicas,statuspath=.status.replicas,selectorpath=.status.selector
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.ready`
+//+kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+//+kubebuilder:printcolumn:name="Master",type=string,JSONPath=`.status.currentMaster`
+//+kubebuilder:printcolumn:name="Zone",type=string,JSONPath=`.status.masterZone`,priority=1
+//+kubebuilder:printcolumn:name="Version",type=string,JSONPath=`.status.version`
+//+kubebuilder:printcolumn:name="Generation",type=integer,JSONPath=`.status.observedGeneration`,priority=1
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // OpenldapCluster is the Schema for the openldapclusters API
 type OpenldapCluster struct {
@@ -187,6 +781,49 @@ func (r *OpenldapCluster) InitContainerName() string {
 	return fmt.Sprintf("%s-init", r.Name)
 }
 
+// DataVolumeClaimName returns the name of the data volume claim created by the
+// statefulset for the pod of the index.
+func (r *OpenldapCluster) DataVolumeClaimName(index int) string {
+	return fmt.Sprintf("data-%s", r.PodName(index))
+}
+
+func (r *OpenldapCluster) GetRetentionPolicy() *appsv1.StatefulSetPersistentVolumeClaimRetentionPolicy {
+	if r.Spec.Storage.PersistentVolumeClaimRetentionPolicy == nil {
+		return &appsv1.StatefulSetPersistentVolumeClaimRetentionPolicy{
+			WhenDeleted: appsv1.RetainPersistentVolumeClaimRetentionPolicyType,
+			WhenScaled:  appsv1.DeletePersistentVolumeClaimRetentionPolicyType,
+		}
+	}
+
+	return r.Spec.Storage.PersistentVolumeClaimRetentionPolicy
+}
+
+func (r *OpenldapCluster) VolumesDeletedWithCluster() bool {
+	return r.GetRetentionPolicy().WhenDeleted == appsv1.DeletePersistentVolumeClaimRetentionPolicyType
+}
+
+// RetainedVolumeLabels marks the volume claims left over by a deleted
+// cluster, they are adopted by a new cluster with the same name only if
+// allowed.
+func (r *OpenldapCluster) RetainedVolumeLabels() map[string]string {
+	return utils.MergeMap(
+		r.SelectorLabels(),
+		map[string]string{RetainedVolumeLabel: "true"},
+	)
+}
+
+func (r *OpenldapCluster) StorageClassName() string {
+	if r.Spec.Storage.VolumeClaimTemplate.StorageClassName == nil {
+		return ""
+	}
+
+	return *r.Spec.Storage.VolumeClaimTemplate.StorageClassName
+}
+
+func (r *OpenldapCluster) StorageRequest() resource.Quantity {
+	return r.Spec.Storage.VolumeClaimTemplate.Resources.Requests[corev1.ResourceStorage]
+}
+
 func (r *OpenldapCluster) PodName(index int) string {
 	return fmt.Sprintf(
 		"%s-%s",
@@ -218,17 +855,19 @@ func (r *OpenldapCluster) SelectorLabels() map[string]string {
 	}
 }
 
-func (r *OpenldapCluster) DefaultLabels() map[string]string {
-	version := "latest"
-
+func (r *OpenldapCluster) ImageVersion() string {
 	if strings.Contains(r.GetTemplate().Image, ":") {
-		version = strings.Split(r.GetTemplate().Image, ":")[1]
+		return strings.Split(r.GetTemplate().Image, ":")[1]
 	}
 
+	return "latest"
+}
+
+func (r *OpenldapCluster) DefaultLabels() map[string]string {
 	return utils.MergeMap(
 		r.GetTemplateLabels(),
 		r.SelectorLabels(),
-		map[string]string{"app.kubernetes.io/version": version},
+		map[string]string{"app.kubernetes.io/version": r.ImageVersion()},
 	)
 }
 
@@ -278,10 +917,59 @@ func (r *OpenldapCluster) WriteServiceName() string {
 	return fmt.Sprintf("%s-write", r.Name)
 }
 
+func (r *OpenldapCluster) HeadlessServiceName() string {
+	return fmt.Sprintf("%s-headless", r.Name)
+}
+
+func (r *OpenldapCluster) PodDnsName(index int) string {
+	return fmt.Sprintf(
+		"%s.%s.%s.svc.cluster.local",
+		r.PodName(index),
+		r.HeadlessServiceName(),
+		r.Namespace,
+	)
+}
+
+// TlsDnsNames are the names the serving certificate must cover, so that the
+// pods verify each other on the per-pod dns names while replicating.
+func (r *OpenldapCluster) TlsDnsNames() []string {
+	names := []string{}
+	for i := 0; i < r.GetReplicas(); i++ {
+		names = append(names, r.PodDnsName(i))
+	}
+
+	return names
+}
+
+func (r *OpenldapCluster) PodUrl(index int) string {
+	return fmt.Sprintf(
+		"%s://%s:%s",
+		r.ServingScheme(),
+		r.PodDnsName(index),
+		strconv.Itoa(int(r.ServingPort())),
+	)
+}
+
 func (r *OpenldapCluster) MetricsServiceName() string {
 	return fmt.Sprintf("%s-metrics", r.Name)
 }
 
+func (r *OpenldapCluster) ReadServiceTemplate() ServiceTemplate {
+	return r.Spec.Services.Read.ServiceTemplate
+}
+
+func (r *OpenldapCluster) ReadExcludeMaster() bool {
+	return r.Spec.Services.Read.ExcludeMaster
+}
+
+func (r *OpenldapCluster) WriteServiceTemplate() ServiceTemplate {
+	return *r.Spec.Services.Write
+}
+
+func (r *OpenldapCluster) MetricsServiceTemplate() ServiceTemplate {
+	return *r.Spec.Services.Metrics
+}
+
 func (r *OpenldapCluster) ConfigMapName() string {
 	return fmt.Sprintf("%s-config", r.Name)
 }
@@ -290,18 +978,56 @@ func (r *OpenldapCluster) ExporterName() string {
 	return fmt.Sprintf("%s-exporter", r.Name)
 }
 
+func (r *OpenldapCluster) GetExporter() ExporterConfig {
+	return *r.Spec.Monitor.Exporter
+}
+
 func (r *OpenldapCluster) ExporterImage() string {
-	return "qwp1216/openldap-exporter:0.0.4"
+	return r.GetExporter().Image
+}
+
+func (r *OpenldapCluster) ExporterBindDn() string {
+	if r.GetExporter().BindDn != "" {
+		return r.GetExporter().BindDn
+	}
+
+	return r.AdminDn()
+}
+
+func (r *OpenldapCluster) ExporterBindPassword() *corev1.SecretKeySelector {
+	if r.GetExporter().BindDn != "" {
+		return r.GetExporter().BindPassword
+	}
+
+	return r.Spec.OpenldapConfig.AdminPassword
 }
 
 func (r *OpenldapCluster) AdminDn() string {
 	return fmt.Sprintf("cn=%s,%s", r.Spec.OpenldapConfig.AdminUsername, r.Spec.OpenldapConfig.Root)
 }
 
+func (r *OpenldapCluster) ConfigAdminDn() string {
+	return fmt.Sprintf("cn=%s,cn=config", r.Spec.OpenldapConfig.ConfigUsername)
+}
+
 func (r *OpenldapCluster) TlsEnabled() bool {
 	return r.Spec.OpenldapConfig.Tls.Enabled
 }
 
+func (r *OpenldapCluster) RequireTls() bool {
+	return r.TlsEnabled() && r.Spec.OpenldapConfig.Tls.RequireTls
+}
+
+func (r *OpenldapCluster) LdapsOnly() bool {
+	return r.TlsEnabled() && r.Spec.OpenldapConfig.Tls.LdapsOnly
+}
+
+// StartTlsRequired checks if clients of the plain port have to upgrade the
+// connection with StartTLS before binding.
+func (r *OpenldapCluster) StartTlsRequired() bool {
+	return r.RequireTls() && !r.LdapsOnly()
+}
+
 func (r *OpenldapCluster) TlsMountPath() string {
 	return "/opt/bitnami/openldap/certs"
 }
@@ -310,8 +1036,50 @@ func (r *OpenldapCluster) MonitorEnabled() bool {
 	return r.Spec.Monitor.Enabled
 }
 
+func (r *OpenldapCluster) NetworkPolicyEnabled() bool {
+	return r.Spec.NetworkPolicy.Enabled
+}
+
+func (r *OpenldapCluster) PodDisruptionBudgetEnabled() bool {
+	return r.Spec.PodDisruptionBudget.Enabled
+}
+
+func (r *OpenldapCluster) SwitchoverOnDrain() bool {
+	return r.Spec.PodDisruptionBudget.SwitchoverOnDrain
+}
+
+func (r *OpenldapCluster) ExporterEnabled() bool {
+	return r.MonitorEnabled() && r.Spec.Monitor.Mode != MonitorModeOperator
+}
+
+func (r *OpenldapCluster) NativeMonitorEnabled() bool {
+	return r.MonitorEnabled() && r.Spec.Monitor.Mode == MonitorModeOperator
+}
+
+func (r *OpenldapCluster) MonitorRulesEnabled() bool {
+	return r.MonitorEnabled() &&
+		r.Spec.Monitor.Rules != nil &&
+		r.Spec.Monitor.Rules.Enabled
+}
+
+// MaxReplicationLag is the tolerated difference of contextCSN between the
+// pods, the same threshold as the replication lag alert.
+func (r *OpenldapCluster) MaxReplicationLag() time.Duration {
+	if r.Spec.Monitor.Rules == nil || r.Spec.Monitor.Rules.ReplicationLagSeconds == 0 {
+		return time.Duration(defaultReplicationLagSeconds) * time.Second
+	}
+
+	return time.Duration(r.Spec.Monitor.Rules.ReplicationLagSeconds) * time.Second
+}
+
+func (r *OpenldapCluster) CertExpiryRuleEnabled() bool {
+	return r.TlsEnabled() &&
+		r.MonitorRulesEnabled() &&
+		r.Spec.Monitor.Rules.CertExpiry.Enabled
+}
+
 func (r *OpenldapCluster) MetricsPort() int32 {
-	return 9142
+	return r.GetExporter().Port
 }
 
 func (r *OpenldapCluster) MetricsPortName() string {
@@ -330,6 +1098,22 @@ func (r *OpenldapCluster) LdapsPort() int32 {
 	return r.GetTemplate().Ports.Ldaps
 }
 
+func (r *OpenldapCluster) ServingPort() int32 {
+	if r.LdapsOnly() {
+		return r.LdapsPort()
+	}
+
+	return r.LdapPort()
+}
+
+func (r *OpenldapCluster) ServingScheme() string {
+	if r.LdapsOnly() {
+		return "ldaps"
+	}
+
+	return "ldap"
+}
+
 func (r *OpenldapCluster) SeedDataPath() string {
 	return "/ldifs"
 }
@@ -358,68 +1142,208 @@ func (r *OpenldapCluster) UpdateCurrentMaster() {
 	r.Status.CurrentMaster = r.Status.DesiredMaster
 }
 
-func (r *OpenldapCluster) DeleteCurrentMaster() {
-	r.Status.CurrentMaster = ""
+func (r *OpenldapCluster) GetMasterZone() string {
+	return r.Status.MasterZone
 }
 
-func (r *OpenldapCluster) IsConditionsEmpty() bool {
-	if r.Status.Conditions == nil {
-		return true
+func (r *OpenldapCluster) UpdateMasterZone(zone string) {
+	r.Status.MasterZone = zone
+}
+
+func (r *OpenldapCluster) PreferredZone() string {
+	return r.Spec.Election.PreferredZone
+}
+
+func (r *OpenldapCluster) ReplicaEnabled() bool {
+	return r.Spec.Replica != nil && r.Spec.Replica.Enabled
+}
+
+func (r *OpenldapCluster) GetReplicaSource() ReplicaSource {
+	return *r.Spec.Replica.Source
+}
+
+// ReplicaSourceUrl returns the provider url of the replica cluster, the url
+// of a referenced cluster is resolved by the operator into the status.
+func (r *OpenldapCluster) ReplicaSourceUrl() string {
+	if url := r.GetReplicaSource().Url; url != "" {
+		return url
 	}
 
-	return len(r.Status.Conditions) == 0
+	if r.Status.Replication == nil {
+		return ""
+	}
+
+	return r.Status.Replication.Source
 }
 
-func (r *OpenldapCluster) GetCondition() metav1.Condition {
-	return r.Status.Conditions[0]
+func (r *OpenldapCluster) ReplicaSourceCluster() *ClusterReference {
+	ref := r.GetReplicaSource().Cluster
+	if ref == nil {
+		return nil
+	}
+
+	namespace := ref.Namespace
+	if namespace == "" {
+		namespace = r.Namespace
+	}
+
+	return &ClusterReference{Name: ref.Name, Namespace: namespace}
 }
 
-func (r *OpenldapCluster) SetInitCondition() {
-	r.Status.Conditions = []metav1.Condition{
-		{
-			Type:               ConditionInitialized,
-			Status:             metav1.ConditionTrue,
-			Reason:             ConditionInitialized,
-			LastTransitionTime: metav1.Now(),
-		},
-		{
-			Type:               ConditionReady,
-			Status:             metav1.ConditionFalse,
-			Reason:             string(metav1.StatusReasonServiceUnavailable),
-			LastTransitionTime: metav1.Now(),
-		},
-		{
-			Type:               ConditionElected,
-			Status:             metav1.ConditionFalse,
-			Reason:             string(metav1.StatusReasonServiceUnavailable),
-			LastTransitionTime: metav1.Now(),
-		},
+func (r *OpenldapCluster) WriteServiceUrl() string {
+	return fmt.Sprintf(
+		"%s://%s.%s.svc.cluster.local:%s",
+		r.ServingScheme(),
+		r.WriteServiceName(),
+		r.Namespace,
+		strconv.Itoa(int(r.ServingPort())),
+	)
+}
+
+func (r *OpenldapCluster) ReplicaSearchBase() string {
+	if base := r.GetReplicaSource().SearchBase; base != "" {
+		return base
 	}
+
+	return r.Spec.OpenldapConfig.Root
 }
 
-func (r *OpenldapCluster) IsInitialized() bool {
-	for _, con := range r.Status.Conditions {
-		if con.Type == ConditionInitialized && con.Status == metav1.ConditionTrue {
-			return true
-		}
+func (r *OpenldapCluster) ReplicaCaMountPath() string {
+	return "/opt/replica/certs"
+}
+
+func (r *OpenldapCluster) ReplicationEnabled() bool {
+	return r.Spec.Replication.Enabled
+}
+
+func (r *OpenldapCluster) GetReplication() ReplicationConfig {
+	return *r.Spec.Replication
+}
+
+// ReplicationManaged checks if the operator writes the syncrepl of the pods
+// instead of the image, providers of a multi-provider cluster always are.
+func (r *OpenldapCluster) ReplicationManaged() bool {
+	return r.ReplicationEnabled() || r.IsMultiProvider()
+}
+
+func (r *OpenldapCluster) DeltaSyncreplEnabled() bool {
+	return r.ReplicationManaged() && r.Spec.Replication.Mode == ReplicationDeltaSyncrepl
+}
+
+func (r *OpenldapCluster) ConfigReplicationEnabled() bool {
+	return r.ReplicationManaged() && r.Spec.Replication.ConfigDatabase
+}
+
+func (r *OpenldapCluster) ReplicationSearchBase() string {
+	if r.Spec.Replication.SearchBase != "" {
+		return r.Spec.Replication.SearchBase
 	}
 
-	return false
+	if r.ReplicaEnabled() {
+		return r.ReplicaSearchBase()
+	}
+
+	return r.Spec.OpenldapConfig.Root
 }
 
-func (r *OpenldapCluster) IsReady() bool {
-	for _, con := range r.Status.Conditions {
-		if con.Type == ConditionReady && con.Status == metav1.ConditionTrue {
-			return true
-		}
+// AccesslogPath is the directory of the cn=accesslog database used by
+// delta-syncrepl, created on the data volume.
+func (r *OpenldapCluster) AccesslogPath() string {
+	return "/bitnami/openldap/accesslog"
+}
+
+func (r *OpenldapCluster) AutoscalingEnabled() bool {
+	return r.Spec.Autoscaling.Enabled
+}
+
+func (r *OpenldapCluster) GetAutoscaling() AutoscalingConfig {
+	return *r.Spec.Autoscaling
+}
+
+// AutoscalingMinReplicas returns the min replicas of the autoscaler, never
+// below two when high availability is requested.
+func (r *OpenldapCluster) AutoscalingMinReplicas() int32 {
+	minReplicas := int32(1)
+	if r.Spec.Autoscaling.MinReplicas != nil {
+		minReplicas = *r.Spec.Autoscaling.MinReplicas
 	}
 
-	return false
+	if r.Spec.Autoscaling.HighAvailability && minReplicas < 2 {
+		return 2
+	}
+
+	return minReplicas
 }
 
-func (r *OpenldapCluster) IsElected() bool {
-	for _, con := range r.Status.Conditions {
-		if con.Type == ConditionElected && con.Status == metav1.ConditionTrue {
+func (r *OpenldapCluster) GetTopologyMode() TopologyMode {
+	return r.Spec.Topology.Mode
+}
+
+// IsMultiProvider checks if more than one pod runs as provider, so that the
+// master is switched without promotion.
+func (r *OpenldapCluster) IsMultiProvider() bool {
+	return r.GetTopologyMode() == TopologyMirrorMode ||
+		r.GetTopologyMode() == TopologyMultiProvider
+}
+
+func (r *OpenldapCluster) ProviderCount() int {
+	switch r.GetTopologyMode() {
+	case TopologyMirrorMode:
+		return 2
+	case TopologyMultiProvider:
+		return r.GetReplicas()
+	default:
+		return 1
+	}
+}
+
+func (r *OpenldapCluster) IsProvider(index int) bool {
+	return !r.IsMultiProvider() || index < r.ProviderCount()
+}
+
+// ServerId returns the olcServerID of the pod of the index.
+func (r *OpenldapCluster) ServerId(index int) int {
+	return index + 1
+}
+
+func (r *OpenldapCluster) FencingEnabled() bool {
+	return r.Spec.Election.Fencing.Enabled
+}
+
+func (r *OpenldapCluster) GetFencing() FencingConfig {
+	return *r.Spec.Election.Fencing
+}
+
+func (r *OpenldapCluster) LeaseName() string {
+	return fmt.Sprintf("%s-master", r.Name)
+}
+
+func (r *OpenldapCluster) FencerName() string {
+	return "fencer"
+}
+
+// PodIndex parses the ordinal of the pod name, regardless of the replicas so
+// that pods about to be scaled down are found. -1 is returned for a pod which
+// does not belong to the cluster.
+func (r *OpenldapCluster) PodIndex(podName string) int {
+	ordinal := strings.TrimPrefix(podName, fmt.Sprintf("%s-", r.Name))
+	if ordinal == podName {
+		return -1
+	}
+
+	index, err := strconv.Atoi(ordinal)
+	if err != nil || index < 0 || r.PodName(index) != podName {
+		return -1
+	}
+
+	return index
+}
+
+// IsMasterScaledDown checks if the current or the desired master is not
+// within the replicas, so that the statefulset must not be scaled down yet.
+func (r *OpenldapCluster) IsMasterScaledDown() bool {
+	for _, master := range []string{r.GetCurrentMaster(), r.GetDesiredMaster()} {
+		if master != "" && r.PodIndex(master) >= r.GetReplicas() {
 			return true
 		}
 	}
@@ -427,63 +1351,207 @@ func (r *OpenldapCluster) IsElected() bool {
 	return false
 }
 
-func (r *OpenldapCluster) SetConditionReady(condition bool) {
-	conditions := []metav1.Condition{}
+func (r *OpenldapCluster) IsElectable(index int) bool {
+	if !r.IsProvider(index) {
+		return false
+	}
 
-	for _, con := range r.Status.Conditions {
-		if con.Type != ConditionReady {
-			conditions = append(conditions, con)
+	for _, ordinal := range r.Spec.Election.ExcludedOrdinals {
+		if int(ordinal) == index {
+			return false
 		}
 	}
 
-	var status metav1.ConditionStatus
-	if condition {
-		status = metav1.ConditionTrue
-	} else {
-		status = metav1.ConditionFalse
+	return true
+}
+
+func (r *OpenldapCluster) ElectionPriority(index int) int32 {
+	for _, priority := range r.Spec.Election.Priorities {
+		if int(priority.Ordinal) == index {
+			return priority.Priority
+		}
 	}
 
-	r.Status.Conditions = append(conditions, metav1.Condition{
-		Type:               ConditionReady,
-		Status:             status,
-		LastTransitionTime: metav1.Now(),
-		Reason:             ConditionReady,
-	})
+	return 0
+}
+
+// PreferredMasterIndex returns the ordinal of the preferred master, -1 if not set.
+func (r *OpenldapCluster) PreferredMasterIndex() int {
+	if r.Spec.Election.PreferredMaster == nil {
+		return -1
+	}
+
+	return int(*r.Spec.Election.PreferredMaster)
 }
 
-func (r *OpenldapCluster) SetConditionElected(condition bool) {
-	conditions := []metav1.Condition{}
+// InitialMasterIndex returns the ordinal of the first master of the cluster.
+func (r *OpenldapCluster) InitialMasterIndex() int {
+	if index := r.PreferredMasterIndex(); index >= 0 && index < r.GetReplicas() {
+		return index
+	}
 
-	for _, con := range r.Status.Conditions {
-		if con.Type != ConditionElected {
-			conditions = append(conditions, con)
+	for i := 0; i < r.GetReplicas(); i++ {
+		if r.IsElectable(i) {
+			return i
 		}
 	}
 
-	var status metav1.ConditionStatus
+	return 0
+}
+
+func (r *OpenldapCluster) DeleteCurrentMaster() {
+	r.Status.CurrentMaster = ""
+}
+
+func (r *OpenldapCluster) GetPhase() ClusterPhase {
+	switch {
+	case r.IsConditionsEmpty() || r.IsInitialized():
+		return PhaseInitializing
+	case r.ReplicaEnabled():
+		return PhaseReplica
+	case r.GetCurrentMaster() == "":
+		return PhaseFailingOver
+	case !r.IsMasterSame():
+		return PhaseSwitchover
+	case r.Status.ReadyReplicas < r.Spec.Replicas:
+		return PhaseDegraded
+	default:
+		return PhaseHealthy
+	}
+}
+
+// UpdateSummary refreshes the status fields shown by kubectl and returns
+// whether anything changed. The replicas are the ones of the statefulset, so
+// that the scale subresource reports the pods actually running.
+func (r *OpenldapCluster) UpdateSummary(replicas int32, readyReplicas int) bool {
+	origin := r.Status.DeepCopy()
+
+	r.Status.Replicas = replicas
+	r.Status.Selector = labels.SelectorFromSet(r.SelectorLabels()).String()
+	r.Status.ReadyReplicas = int32(readyReplicas)
+	r.Status.Ready = fmt.Sprintf("%d/%d", readyReplicas, r.Spec.Replicas)
+	r.Status.Version = r.ImageVersion()
+	r.Status.ObservedGeneration = r.Generation
+	r.Status.Phase = r.GetPhase()
+
+	return origin.Phase != r.Status.Phase ||
+		origin.Replicas != r.Status.Replicas ||
+		origin.Selector != r.Status.Selector ||
+		origin.ReadyReplicas != r.Status.ReadyReplicas ||
+		origin.Version != r.Status.Version ||
+		origin.ObservedGeneration != r.Status.ObservedGeneration
+}
+
+func (r *OpenldapCluster) IsConditionsEmpty() bool {
+	if r.Status.Conditions == nil {
+		return true
+	}
+
+	return len(r.Status.Conditions) == 0
+}
+
+func (r *OpenldapCluster) GetCondition(conditionType string) *metav1.Condition {
+	return meta.FindStatusCondition(r.Status.Conditions, conditionType)
+}
+
+// SetCondition sets the condition following meta.SetStatusCondition semantics,
+// the transition time is only changed when the status changes.
+func (r *OpenldapCluster) SetCondition(
+	conditionType string,
+	condition bool,
+	reason string,
+	message string,
+) {
+	status := metav1.ConditionFalse
 	if condition {
 		status = metav1.ConditionTrue
-	} else {
-		status = metav1.ConditionFalse
 	}
 
-	r.Status.Conditions = append(conditions, metav1.Condition{
-		Type:               ConditionElected,
+	r.SetConditionStatus(conditionType, status, reason, message)
+}
+
+func (r *OpenldapCluster) SetConditionStatus(
+	conditionType string,
+	status metav1.ConditionStatus,
+	reason string,
+	message string,
+) {
+	meta.SetStatusCondition(&r.Status.Conditions, metav1.Condition{
+		Type:               conditionType,
 		Status:             status,
-		LastTransitionTime: metav1.Now(),
-		Reason:             ConditionElected,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: r.Generation,
 	})
 }
 
-func (r *OpenldapCluster) DeleteInitializedCondition() {
-	conditions := []metav1.Condition{}
-	for _, con := range r.Status.Conditions {
-		if con.Type != ConditionInitialized {
-			conditions = append(conditions, con)
-		}
+func (r *OpenldapCluster) RemoveCondition(conditionType string) {
+	meta.RemoveStatusCondition(&r.Status.Conditions, conditionType)
+}
+
+func (r *OpenldapCluster) SetInitCondition() {
+	r.SetCondition(
+		ConditionInitialized,
+		true,
+		ReasonClusterCreated,
+		"Cluster is initializing",
+	)
+	r.SetCondition(
+		ConditionReady,
+		false,
+		ReasonMasterNotElected,
+		"Waiting for first master to be elected",
+	)
+	r.SetCondition(
+		ConditionElected,
+		false,
+		ReasonMasterNotElected,
+		"Waiting for first master to be elected",
+	)
+}
+
+func (r *OpenldapCluster) IsInitialized() bool {
+	return meta.IsStatusConditionTrue(r.Status.Conditions, ConditionInitialized)
+}
+
+func (r *OpenldapCluster) IsReady() bool {
+	return meta.IsStatusConditionTrue(r.Status.Conditions, ConditionReady)
+}
+
+func (r *OpenldapCluster) IsElected() bool {
+	return meta.IsStatusConditionTrue(r.Status.Conditions, ConditionElected)
+}
+
+func (r *OpenldapCluster) SetConditionReady(condition bool, reason string, message string) {
+	r.SetCondition(ConditionReady, condition, reason, message)
+}
+
+func (r *OpenldapCluster) SetConditionElected(condition bool, reason string, message string) {
+	r.SetCondition(ConditionElected, condition, reason, message)
+}
+
+func (r *OpenldapCluster) SetConditionProgressing(condition bool, reason string, message string) {
+	r.SetCondition(ConditionProgressing, condition, reason, message)
+}
+
+func (r *OpenldapCluster) SetConditionDegraded(condition bool, reason string, message string) {
+	r.SetCondition(ConditionDegraded, condition, reason, message)
+}
+
+func (r *OpenldapCluster) SetConditionTlsReady(condition bool, reason string, message string) {
+	r.SetCondition(ConditionTlsReady, condition, reason, message)
+}
+
+func (r *OpenldapCluster) GetReplicationStatus() *ReplicationStatus {
+	if r.Status.Replication == nil {
+		r.Status.Replication = &ReplicationStatus{}
 	}
 
-	r.Status.Conditions = conditions
+	return r.Status.Replication
+}
+
+func (r *OpenldapCluster) DeleteInitializedCondition() {
+	r.RemoveCondition(ConditionInitialized)
 }
 
 func init() {
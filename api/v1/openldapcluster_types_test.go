@@ -0,0 +1,188 @@
+package v1_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	openldapv1 "github.com/qwp0905/openldap-operator/api/v1"
+	"github.com/qwp0905/openldap-operator/internal/fixtures"
+)
+
+// electedCluster returns a cluster done initializing with ldap-0 elected.
+func electedCluster() *openldapv1.OpenldapCluster {
+	cluster := fixtures.NewCluster("ldap", 3)
+	cluster.SetInitCondition()
+	cluster.DeleteInitializedCondition()
+	cluster.UpdateDesiredMaster(0)
+	cluster.UpdateCurrentMaster()
+
+	return cluster
+}
+
+var _ = Describe("OpenldapCluster status summary", func() {
+	It("is initializing until the first election", func() {
+		cluster := fixtures.NewCluster("ldap", 3)
+		Expect(cluster.GetPhase()).To(Equal(openldapv1.PhaseInitializing))
+
+		cluster.SetInitCondition()
+		Expect(cluster.GetPhase()).To(Equal(openldapv1.PhaseInitializing))
+	})
+
+	It("follows the master and the ready replicas", func() {
+		cluster := electedCluster()
+		cluster.UpdateSummary(3, 3)
+		Expect(cluster.Status.Phase).To(Equal(openldapv1.PhaseHealthy))
+
+		cluster.UpdateSummary(3, 2)
+		Expect(cluster.Status.Phase).To(Equal(openldapv1.PhaseDegraded))
+
+		cluster.UpdateDesiredMaster(1)
+		Expect(cluster.GetPhase()).To(Equal(openldapv1.PhaseSwitchover))
+
+		cluster.DeleteCurrentMaster()
+		Expect(cluster.GetPhase()).To(Equal(openldapv1.PhaseFailingOver))
+	})
+
+	It("reports whether the summary changed", func() {
+		cluster := electedCluster()
+		cluster.Generation = 2
+
+		Expect(cluster.UpdateSummary(3, 3)).To(BeTrue())
+		Expect(cluster.Status.Ready).To(Equal("3/3"))
+		Expect(cluster.Status.Version).To(Equal("2.6.4"))
+		Expect(cluster.Status.ObservedGeneration).To(Equal(int64(2)))
+
+		Expect(cluster.UpdateSummary(3, 3)).To(BeFalse())
+		Expect(cluster.UpdateSummary(3, 1)).To(BeTrue())
+		Expect(cluster.Status.Ready).To(Equal("1/3"))
+	})
+
+	It("reports the replicas of the statefulset for the scale subresource", func() {
+		cluster := electedCluster()
+		cluster.Spec.Replicas = 2
+
+		Expect(cluster.UpdateSummary(3, 3)).To(BeTrue())
+		Expect(cluster.Status.Replicas).To(Equal(int32(3)))
+		Expect(cluster.Status.Selector).To(ContainSubstring("app.kubernetes.io/name=ldap"))
+	})
+
+	It("never autoscales below two pods if high availability is requested", func() {
+		cluster := fixtures.NewCluster("ldap", 3)
+		one := int32(1)
+		cluster.Spec.Autoscaling.MinReplicas = &one
+		Expect(cluster.AutoscalingMinReplicas()).To(Equal(int32(2)))
+
+		cluster.Spec.Autoscaling.HighAvailability = false
+		Expect(cluster.AutoscalingMinReplicas()).To(Equal(int32(1)))
+	})
+})
+
+var _ = Describe("OpenldapCluster defaults", func() {
+	It("spreads the pods of new clusters across zones", func() {
+		constraints := fixtures.NewCluster("ldap", 3).GetTemplate().TopologySpreadConstraints
+
+		Expect(constraints).To(HaveLen(1))
+		Expect(constraints[0].TopologyKey).To(Equal(corev1.LabelTopologyZone))
+	})
+
+	It("does not spread the pods of existing clusters", func() {
+		cluster := fixtures.NewCluster("ldap", 3)
+		cluster.CreationTimestamp = metav1.Now()
+		cluster.Spec.Template.TopologySpreadConstraints = nil
+		cluster.SetDefault()
+
+		Expect(cluster.GetTemplate().TopologySpreadConstraints).To(BeNil())
+	})
+
+	It("retains the volume claims on deletion and deletes them on scaling down", func() {
+		cluster := fixtures.NewCluster("ldap", 3)
+
+		Expect(cluster.GetRetentionPolicy()).To(Equal(&appsv1.StatefulSetPersistentVolumeClaimRetentionPolicy{
+			WhenDeleted: appsv1.RetainPersistentVolumeClaimRetentionPolicyType,
+			WhenScaled:  appsv1.DeletePersistentVolumeClaimRetentionPolicyType,
+		}))
+		Expect(cluster.VolumesDeletedWithCluster()).To(BeFalse())
+
+		cluster.Spec.Storage.PersistentVolumeClaimRetentionPolicy.WhenDeleted =
+			appsv1.DeletePersistentVolumeClaimRetentionPolicyType
+		Expect(cluster.VolumesDeletedWithCluster()).To(BeTrue())
+	})
+})
+
+var _ = Describe("OpenldapCluster election", func() {
+	It("finds the ordinal of the pods of the cluster only", func() {
+		cluster := fixtures.NewCluster("ldap", 3)
+
+		Expect(cluster.PodIndex("ldap-2")).To(Equal(2))
+		Expect(cluster.PodIndex("ldap-3")).To(Equal(3))
+		Expect(cluster.PodIndex("ldap-03")).To(Equal(-1))
+		Expect(cluster.PodIndex("ldap-exporter")).To(Equal(-1))
+		Expect(cluster.PodIndex("other-0")).To(Equal(-1))
+	})
+
+	It("detects a master removed by scaling down", func() {
+		cluster := fixtures.NewCluster("ldap", 3)
+		cluster.UpdateDesiredMaster(2)
+		cluster.UpdateCurrentMaster()
+		Expect(cluster.IsMasterScaledDown()).To(BeFalse())
+
+		cluster.Spec.Replicas = 2
+		Expect(cluster.IsMasterScaledDown()).To(BeTrue())
+
+		cluster.UpdateDesiredMaster(0)
+		cluster.UpdateCurrentMaster()
+		Expect(cluster.IsMasterScaledDown()).To(BeFalse())
+	})
+
+	It("starts on the first electable pod", func() {
+		cluster := fixtures.NewCluster("ldap", 3)
+		Expect(cluster.InitialMasterIndex()).To(Equal(0))
+
+		cluster.Spec.Election.ExcludedOrdinals = []int32{0}
+		Expect(cluster.InitialMasterIndex()).To(Equal(1))
+	})
+
+	It("elects only the providers of a mirror mode cluster", func() {
+		cluster := fixtures.WithTopology(fixtures.NewCluster("ldap", 3), openldapv1.TopologyMirrorMode)
+
+		Expect(cluster.IsMultiProvider()).To(BeTrue())
+		Expect(cluster.ProviderCount()).To(Equal(2))
+		Expect(cluster.IsElectable(1)).To(BeTrue())
+		Expect(cluster.IsElectable(2)).To(BeFalse())
+		Expect(cluster.ServerId(2)).To(Equal(3))
+	})
+
+	It("elects any pod of a single master or multi-provider cluster", func() {
+		for _, mode := range []openldapv1.TopologyMode{
+			openldapv1.TopologySingleMaster,
+			openldapv1.TopologyMultiProvider,
+		} {
+			cluster := fixtures.WithTopology(fixtures.NewCluster("ldap", 3), mode)
+
+			Expect(cluster.IsElectable(2)).To(BeTrue())
+		}
+	})
+})
+
+var _ = Describe("OpenldapCluster replica source", func() {
+	It("reads the url resolved in the status for a referenced cluster", func() {
+		cluster := fixtures.WithReplica(fixtures.NewCluster("ldap", 3), "")
+		cluster.Spec.Replica.Source.Cluster = &openldapv1.ClusterReference{Name: "source"}
+		Expect(cluster.ReplicaSourceUrl()).To(BeEmpty())
+		Expect(cluster.ReplicaSourceCluster()).To(Equal(&openldapv1.ClusterReference{Name: "source", Namespace: "default"}))
+
+		cluster.GetReplicationStatus().Source = "ldap://source-write.default.svc.cluster.local:1389"
+		Expect(cluster.ReplicaSourceUrl()).To(Equal("ldap://source-write.default.svc.cluster.local:1389"))
+	})
+
+	It("prefers the url of the source", func() {
+		cluster := fixtures.WithReplica(fixtures.NewCluster("ldap", 3), "ldap://ldap.example.com")
+		cluster.GetReplicationStatus().Source = "ldap://other"
+
+		Expect(cluster.ReplicaSourceUrl()).To(Equal("ldap://ldap.example.com"))
+		Expect(cluster.ReplicaSourceCluster()).To(BeNil())
+	})
+})
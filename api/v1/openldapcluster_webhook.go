@@ -17,15 +17,23 @@ limitations under the License.
 package v1
 
 import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
 const (
@@ -34,6 +42,33 @@ const (
 	defaultMonitorEnabled = false
 	defaultAdmin          = "admin"
 	defaultConfig         = "config"
+
+	defaultExporterImage = "qwp1216/openldap-exporter:0.0.4"
+	defaultMetricsPort   = 9142
+
+	defaultReplicationLagSeconds = 60
+
+	defaultNetworkPolicyEnabled = false
+	defaultMetricsNamespace     = "monitoring"
+
+	defaultPodDisruptionBudgetEnabled = true
+	defaultSwitchoverOnDrain          = true
+	defaultMaxUnavailable             = 1
+
+	defaultFencingEnabled       = false
+	defaultLeaseDurationSeconds = 30
+	defaultFencingDatabaseDn    = "olcDatabase={2}mdb,cn=config"
+
+	defaultReplicationEnabled    = false
+	defaultReplicationInterval   = "00:00:05:00"
+	defaultReplicationRetry      = "60 +"
+	defaultReplicationTimeout    = 10
+	defaultReplicationKeepalive  = "240:10:30"
+	defaultReplicationDatabaseDn = "olcDatabase={2}mdb,cn=config"
+
+	defaultAutoscalingEnabled          = false
+	defaultAutoscalingHighAvailability = true
+	defaultSearchRateMetric            = "openldap_search_operations_per_second"
 )
 
 // log is for logging in this package.
@@ -42,6 +77,7 @@ var openldapclusterlog = logf.Log.WithName("openldapcluster-resource")
 func (r *OpenldapCluster) SetupWebhookWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewWebhookManagedBy(mgr).
 		For(r).
+		WithValidator(&OpenldapClusterValidator{}).
 		Complete()
 }
 
@@ -58,18 +94,82 @@ func (r *OpenldapCluster) Default() {
 
 //+kubebuilder:webhook:path=/validate-openldap-kwonjin-click-v1-openldapcluster,mutating=false,failurePolicy=fail,sideEffects=None,groups=openldap.kwonjin.click,resources=openldapclusters,verbs=create;update,versions=v1,name=vopenldapcluster.kb.io,admissionReviewVersions=v1
 
-var _ webhook.Validator = &OpenldapCluster{}
+// OpenldapClusterValidator validates the clusters, unlike webhook.Validator
+// it is able to warn on an accepted change.
+// +kubebuilder:object:generate=false
+type OpenldapClusterValidator struct{}
+
+var _ webhook.CustomValidator = &OpenldapClusterValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type
+func (v *OpenldapClusterValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	cluster, ok := obj.(*OpenldapCluster)
+	if !ok {
+		return nil, fmt.Errorf("expected an OpenldapCluster but got %T", obj)
+	}
+	openldapclusterlog.Info("validate create", "name", cluster.Name)
+
+	return nil, cluster.Validate()
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type
+func (v *OpenldapClusterValidator) ValidateUpdate(
+	ctx context.Context,
+	oldObj runtime.Object,
+	newObj runtime.Object,
+) (admission.Warnings, error) {
+	cluster, ok := newObj.(*OpenldapCluster)
+	if !ok {
+		return nil, fmt.Errorf("expected an OpenldapCluster but got %T", newObj)
+	}
+	oldCluster, ok := oldObj.(*OpenldapCluster)
+	if !ok {
+		return nil, fmt.Errorf("expected an OpenldapCluster but got %T", oldObj)
+	}
+	openldapclusterlog.Info("validate update", "name", cluster.Name)
+
+	oldCluster.SetDefault()
+
+	return cluster.updateWarnings(oldCluster), cluster.ValidateChange(oldCluster)
+}
 
-// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
-func (r *OpenldapCluster) ValidateCreate() error {
-	openldapclusterlog.Info("validate create", "name", r.Name)
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type
+func (v *OpenldapClusterValidator) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
 
+// Validate checks the spec of the cluster on its own.
+func (r *OpenldapCluster) Validate() error {
 	apierrs := field.ErrorList{}
 
 	if err := r.validateTlsSecret(); err != nil {
 		apierrs = append(apierrs, err)
 	}
 
+	apierrs = append(apierrs, r.validateTlsOptions()...)
+	apierrs = append(apierrs, r.validateServices()...)
+	apierrs = append(apierrs, r.validateElection()...)
+
+	if err := r.validateTopology(); err != nil {
+		apierrs = append(apierrs, err)
+	}
+
+	apierrs = append(apierrs, r.validateReplica()...)
+
+	if err := r.validateReplication(); err != nil {
+		apierrs = append(apierrs, err)
+	}
+
+	apierrs = append(apierrs, r.validateAutoscaling()...)
+
+	if err := r.validateExporterBind(); err != nil {
+		apierrs = append(apierrs, err)
+	}
+
+	if err := r.validatePodDisruptionBudget(); err != nil {
+		apierrs = append(apierrs, err)
+	}
+
 	if len(apierrs) > 0 {
 		return r.createError(apierrs)
 	}
@@ -77,33 +177,68 @@ func (r *OpenldapCluster) ValidateCreate() error {
 	return nil
 }
 
-// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
-func (r *OpenldapCluster) ValidateUpdate(old runtime.Object) error {
-	openldapclusterlog.Info("validate update", "name", r.Name)
+// updateWarnings warns on accepted changes which leave the cluster without
+// failover.
+func (r *OpenldapCluster) updateWarnings(oldCluster *OpenldapCluster) admission.Warnings {
+	if r.Spec.Replicas == 1 && oldCluster.Spec.Replicas > 1 {
+		return admission.Warnings{"Scaling down to a single replica, the cluster cannot fail over"}
+	}
 
-	oldCluster := old.(*OpenldapCluster)
-	oldCluster.SetDefault()
+	return nil
+}
 
+// ValidateChange checks the spec of the cluster and the change from the old
+// one.
+func (r *OpenldapCluster) ValidateChange(oldCluster *OpenldapCluster) error {
 	apierrs := field.ErrorList{}
 
 	if err := r.validateTlsSecret(); err != nil {
 		apierrs = append(apierrs, err)
 	}
 
+	apierrs = append(apierrs, r.validateTlsOptions()...)
+	apierrs = append(apierrs, r.validateServices()...)
+	apierrs = append(apierrs, r.validateElection()...)
+
+	if err := r.validateTopology(); err != nil {
+		apierrs = append(apierrs, err)
+	}
+
+	apierrs = append(apierrs, r.validateReplica()...)
+
+	if err := r.validateReplication(); err != nil {
+		apierrs = append(apierrs, err)
+	}
+
+	apierrs = append(apierrs, r.validateAutoscaling()...)
+
+	if err := r.validateExporterBind(); err != nil {
+		apierrs = append(apierrs, err)
+	}
+
+	if err := r.validatePodDisruptionBudget(); err != nil {
+		apierrs = append(apierrs, err)
+	}
+
 	if err := r.validateTlsChanged(oldCluster); err != nil {
 		apierrs = append(apierrs, err)
 	}
 
-	if len(apierrs) > 0 {
-		return r.createError(apierrs)
+	if err := r.validateTopologyChanged(oldCluster); err != nil {
+		apierrs = append(apierrs, err)
 	}
 
-	return nil
-}
+	if err := r.validateReplicaChanged(oldCluster); err != nil {
+		apierrs = append(apierrs, err)
+	}
+
+	if err := r.validateStorageChanged(oldCluster); err != nil {
+		apierrs = append(apierrs, err)
+	}
 
-// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
-func (r *OpenldapCluster) ValidateDelete() error {
-	openldapclusterlog.Info("validate delete", "name", r.Name)
+	if len(apierrs) > 0 {
+		return r.createError(apierrs)
+	}
 
 	return nil
 }
@@ -135,12 +270,63 @@ func (r *OpenldapCluster) SetDefault() {
 		r.Spec.OpenldapConfig.ConfigUsername = defaultConfig
 	}
 
+	if r.Spec.Storage != nil {
+		if r.Spec.Storage.PersistentVolumeClaimRetentionPolicy == nil {
+			r.Spec.Storage.PersistentVolumeClaimRetentionPolicy =
+				&appsv1.StatefulSetPersistentVolumeClaimRetentionPolicy{}
+		}
+
+		if r.Spec.Storage.PersistentVolumeClaimRetentionPolicy.WhenDeleted == "" {
+			r.Spec.Storage.PersistentVolumeClaimRetentionPolicy.WhenDeleted =
+				appsv1.RetainPersistentVolumeClaimRetentionPolicyType
+		}
+
+		if r.Spec.Storage.PersistentVolumeClaimRetentionPolicy.WhenScaled == "" {
+			r.Spec.Storage.PersistentVolumeClaimRetentionPolicy.WhenScaled =
+				appsv1.DeletePersistentVolumeClaimRetentionPolicyType
+		}
+	}
+
 	if r.Spec.Monitor == nil {
 		r.Spec.Monitor = &MonitorConfig{
 			Enabled: defaultMonitorEnabled,
 		}
 	}
 
+	if r.Spec.Monitor.Mode == "" {
+		r.Spec.Monitor.Mode = MonitorModeExporter
+	}
+
+	if r.Spec.Monitor.Exporter == nil {
+		r.Spec.Monitor.Exporter = &ExporterConfig{}
+	}
+
+	if r.Spec.Monitor.Exporter.Image == "" {
+		r.Spec.Monitor.Exporter.Image = defaultExporterImage
+	}
+
+	if r.Spec.Monitor.Exporter.ImagePullPolicy == "" {
+		r.Spec.Monitor.Exporter.ImagePullPolicy = corev1.PullIfNotPresent
+	}
+
+	if r.Spec.Monitor.Exporter.Port == 0 {
+		r.Spec.Monitor.Exporter.Port = defaultMetricsPort
+	}
+
+	if r.Spec.Monitor.Exporter.Resources.Limits == nil &&
+		r.Spec.Monitor.Exporter.Resources.Requests == nil {
+		r.Spec.Monitor.Exporter.Resources = corev1.ResourceRequirements{
+			Limits: corev1.ResourceList{
+				"cpu":    resource.MustParse("50m"),
+				"memory": resource.MustParse("64Mi"),
+			},
+			Requests: corev1.ResourceList{
+				"cpu":    resource.MustParse("50m"),
+				"memory": resource.MustParse("64Mi"),
+			},
+		}
+	}
+
 	if r.MonitorEnabled() {
 		if r.Spec.Monitor.Interval == "" {
 			r.Spec.Monitor.Interval = "30s"
@@ -151,6 +337,91 @@ func (r *OpenldapCluster) SetDefault() {
 		}
 	}
 
+	if r.MonitorRulesEnabled() {
+		if r.Spec.Monitor.Rules.For == "" {
+			r.Spec.Monitor.Rules.For = "5m"
+		}
+
+		if r.Spec.Monitor.Rules.ReplicationLagSeconds == 0 {
+			r.Spec.Monitor.Rules.ReplicationLagSeconds = defaultReplicationLagSeconds
+		}
+
+		if r.Spec.Monitor.Rules.MaxConnections == 0 {
+			r.Spec.Monitor.Rules.MaxConnections = 1000
+		}
+
+		if r.Spec.Monitor.Rules.CertExpiry == nil {
+			r.Spec.Monitor.Rules.CertExpiry = &CertExpiryRuleConfig{}
+		}
+
+		if r.Spec.Monitor.Rules.CertExpiry.Metric == "" {
+			r.Spec.Monitor.Rules.CertExpiry.Metric = "x509_cert_not_after"
+		}
+
+		if r.Spec.Monitor.Rules.CertExpiry.Days == 0 {
+			r.Spec.Monitor.Rules.CertExpiry.Days = 14
+		}
+	}
+
+	if r.Spec.Services == nil {
+		r.Spec.Services = &ServicesConfig{}
+	}
+
+	if r.Spec.Services.Read == nil {
+		r.Spec.Services.Read = &ReadServiceTemplate{}
+	}
+
+	if r.Spec.Services.Write == nil {
+		r.Spec.Services.Write = &ServiceTemplate{}
+	}
+
+	if r.Spec.Services.Metrics == nil {
+		r.Spec.Services.Metrics = &ServiceTemplate{}
+	}
+
+	if r.Spec.Services.Read.Type == "" {
+		r.Spec.Services.Read.Type = corev1.ServiceTypeClusterIP
+	}
+
+	if r.Spec.Services.Write.Type == "" {
+		r.Spec.Services.Write.Type = corev1.ServiceTypeClusterIP
+	}
+
+	if r.Spec.Services.Metrics.Type == "" {
+		r.Spec.Services.Metrics.Type = corev1.ServiceTypeClusterIP
+	}
+
+	if r.Spec.NetworkPolicy == nil {
+		r.Spec.NetworkPolicy = &NetworkPolicyConfig{
+			Enabled: defaultNetworkPolicyEnabled,
+		}
+	}
+
+	if r.NetworkPolicyEnabled() && r.Spec.NetworkPolicy.MetricsFrom == nil {
+		r.Spec.NetworkPolicy.MetricsFrom = []networkingv1.NetworkPolicyPeer{
+			{
+				NamespaceSelector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{
+						"kubernetes.io/metadata.name": defaultMetricsNamespace,
+					},
+				},
+			},
+		}
+	}
+
+	if r.Spec.PodDisruptionBudget == nil {
+		r.Spec.PodDisruptionBudget = &PodDisruptionBudgetConfig{
+			Enabled:           defaultPodDisruptionBudgetEnabled,
+			SwitchoverOnDrain: defaultSwitchoverOnDrain,
+		}
+	}
+
+	if r.Spec.PodDisruptionBudget.MinAvailable == nil &&
+		r.Spec.PodDisruptionBudget.MaxUnavailable == nil {
+		maxUnavailable := intstr.FromInt(defaultMaxUnavailable)
+		r.Spec.PodDisruptionBudget.MaxUnavailable = &maxUnavailable
+	}
+
 	if r.GetTemplate().Ports == nil {
 		r.Spec.Template.Ports = &PortConfig{
 			Ldap:  1389,
@@ -176,6 +447,93 @@ func (r *OpenldapCluster) SetDefault() {
 		r.Spec.Template.Image = "qwp1216/openldap:2.6.4"
 	}
 
+	// Only clusters being created get the zone spread, so that upgrading the
+	// operator does not roll the pods of existing clusters.
+	if r.GetTemplate().TopologySpreadConstraints == nil && r.CreationTimestamp.IsZero() {
+		r.Spec.Template.TopologySpreadConstraints = []corev1.TopologySpreadConstraint{
+			{
+				MaxSkew:           1,
+				TopologyKey:       corev1.LabelTopologyZone,
+				WhenUnsatisfiable: corev1.ScheduleAnyway,
+				LabelSelector: &metav1.LabelSelector{
+					MatchLabels: r.SelectorLabels(),
+				},
+			},
+		}
+	}
+
+	if r.Spec.Election == nil {
+		r.Spec.Election = &ElectionConfig{}
+	}
+
+	if r.Spec.Replication == nil {
+		r.Spec.Replication = &ReplicationConfig{
+			Enabled: defaultReplicationEnabled,
+		}
+	}
+
+	if r.Spec.Replication.Mode == "" {
+		r.Spec.Replication.Mode = ReplicationSyncrepl
+	}
+
+	if r.Spec.Replication.Interval == "" {
+		r.Spec.Replication.Interval = defaultReplicationInterval
+	}
+
+	if r.Spec.Replication.Retry == "" {
+		r.Spec.Replication.Retry = defaultReplicationRetry
+	}
+
+	if r.Spec.Replication.Timeout == 0 {
+		r.Spec.Replication.Timeout = defaultReplicationTimeout
+	}
+
+	if r.Spec.Replication.Keepalive == "" {
+		r.Spec.Replication.Keepalive = defaultReplicationKeepalive
+	}
+
+	if r.Spec.Replication.DatabaseDn == "" {
+		r.Spec.Replication.DatabaseDn = defaultReplicationDatabaseDn
+	}
+
+	if r.Spec.Autoscaling == nil {
+		r.Spec.Autoscaling = &AutoscalingConfig{
+			Enabled:          defaultAutoscalingEnabled,
+			HighAvailability: defaultAutoscalingHighAvailability,
+		}
+	}
+
+	if r.Spec.Autoscaling.MinReplicas == nil {
+		minReplicas := r.AutoscalingMinReplicas()
+		r.Spec.Autoscaling.MinReplicas = &minReplicas
+	}
+
+	if r.Spec.Autoscaling.SearchRate != nil && r.Spec.Autoscaling.SearchRate.Metric == "" {
+		r.Spec.Autoscaling.SearchRate.Metric = defaultSearchRateMetric
+	}
+
+	if r.Spec.Topology == nil {
+		r.Spec.Topology = &TopologyConfig{}
+	}
+
+	if r.Spec.Topology.Mode == "" {
+		r.Spec.Topology.Mode = TopologySingleMaster
+	}
+
+	if r.Spec.Election.Fencing == nil {
+		r.Spec.Election.Fencing = &FencingConfig{
+			Enabled: defaultFencingEnabled,
+		}
+	}
+
+	if r.Spec.Election.Fencing.LeaseDurationSeconds == 0 {
+		r.Spec.Election.Fencing.LeaseDurationSeconds = defaultLeaseDurationSeconds
+	}
+
+	if r.Spec.Election.Fencing.DatabaseDn == "" {
+		r.Spec.Election.Fencing.DatabaseDn = defaultFencingDatabaseDn
+	}
+
 	if r.GetTemplate().Affinity == nil {
 		r.Spec.Template.Affinity = &corev1.Affinity{
 			PodAntiAffinity: &corev1.PodAntiAffinity{
@@ -228,3 +586,355 @@ func (r *OpenldapCluster) validateTlsChanged(old *OpenldapCluster) *field.Error
 
 	return nil
 }
+
+func (r *OpenldapCluster) validateStorageChanged(old *OpenldapCluster) *field.Error {
+	if r.StorageClassName() != old.StorageClassName() && r.GetReplicas() == 1 {
+		return &field.Error{
+			Type:     field.ErrorTypeForbidden,
+			Field:    "spec.storage.volumeClaimTemplate.storageClassName",
+			BadValue: r.StorageClassName(),
+			Detail:   "Storage class is migrated by rebuilding replicas, at least 2 replicas are required",
+		}
+	}
+
+	if r.StorageClassName() != old.StorageClassName() && !r.NativeMonitorEnabled() {
+		return &field.Error{
+			Type:     field.ErrorTypeForbidden,
+			Field:    "spec.storage.volumeClaimTemplate.storageClassName",
+			BadValue: r.StorageClassName(),
+			Detail:   "Storage class is migrated only in the operator monitor mode, which observes the resync of rebuilt replicas",
+		}
+	}
+
+	size := r.StorageRequest()
+	if size.Cmp(old.StorageRequest()) < 0 {
+		return &field.Error{
+			Type:     field.ErrorTypeForbidden,
+			Field:    "spec.storage.volumeClaimTemplate.resources.requests.storage",
+			BadValue: size.String(),
+			Detail:   "Storage cannot be shrunk",
+		}
+	}
+
+	return nil
+}
+
+func (r *OpenldapCluster) validatePodDisruptionBudget() *field.Error {
+	if r.Spec.PodDisruptionBudget.MinAvailable != nil &&
+		r.Spec.PodDisruptionBudget.MaxUnavailable != nil {
+		return &field.Error{
+			Type:     field.ErrorTypeForbidden,
+			Field:    "spec.podDisruptionBudget.minAvailable",
+			BadValue: r.Spec.PodDisruptionBudget.MinAvailable.String(),
+			Detail:   "Only one of minAvailable and maxUnavailable can be set",
+		}
+	}
+
+	return nil
+}
+
+func (r *OpenldapCluster) validateTlsOptions() field.ErrorList {
+	apierrs := field.ErrorList{}
+
+	if r.TlsEnabled() {
+		return apierrs
+	}
+
+	if r.Spec.OpenldapConfig.Tls.RequireTls {
+		apierrs = append(apierrs, &field.Error{
+			Type:     field.ErrorTypeForbidden,
+			Field:    "spec.openldapConfig.tls.requireTls",
+			BadValue: r.Spec.OpenldapConfig.Tls.RequireTls,
+			Detail:   "If tls disabled, starttls cannot be required",
+		})
+	}
+
+	if r.Spec.OpenldapConfig.Tls.LdapsOnly {
+		apierrs = append(apierrs, &field.Error{
+			Type:     field.ErrorTypeForbidden,
+			Field:    "spec.openldapConfig.tls.ldapsOnly",
+			BadValue: r.Spec.OpenldapConfig.Tls.LdapsOnly,
+			Detail:   "If tls disabled, plain ldap port cannot be dropped",
+		})
+	}
+
+	return apierrs
+}
+
+func (r *OpenldapCluster) validateServices() field.ErrorList {
+	apierrs := field.ErrorList{}
+
+	names := []string{"read", "write", "metrics"}
+	templates := []ServiceTemplate{
+		r.ReadServiceTemplate(),
+		r.WriteServiceTemplate(),
+		r.MetricsServiceTemplate(),
+	}
+
+	for i, template := range templates {
+		path := fmt.Sprintf("spec.services.%s", names[i])
+
+		if template.Type == corev1.ServiceTypeClusterIP && len(template.NodePorts) > 0 {
+			apierrs = append(apierrs, &field.Error{
+				Type:     field.ErrorTypeForbidden,
+				Field:    path + ".nodePorts",
+				BadValue: template.NodePorts,
+				Detail:   "Node ports require service type NodePort or LoadBalancer",
+			})
+		}
+
+		if template.Type == corev1.ServiceTypeClusterIP && template.ExternalTrafficPolicy != "" {
+			apierrs = append(apierrs, &field.Error{
+				Type:     field.ErrorTypeForbidden,
+				Field:    path + ".externalTrafficPolicy",
+				BadValue: template.ExternalTrafficPolicy,
+				Detail:   "External traffic policy requires service type NodePort or LoadBalancer",
+			})
+		}
+
+		if template.Type != corev1.ServiceTypeLoadBalancer &&
+			(template.LoadBalancerClass != nil || len(template.LoadBalancerSourceRanges) > 0) {
+			apierrs = append(apierrs, &field.Error{
+				Type:     field.ErrorTypeForbidden,
+				Field:    path + ".type",
+				BadValue: template.Type,
+				Detail:   "Load balancer options require service type LoadBalancer",
+			})
+		}
+	}
+
+	return apierrs
+}
+
+func (r *OpenldapCluster) validateExporterBind() *field.Error {
+	if r.GetExporter().BindDn != "" && r.GetExporter().BindPassword == nil {
+		return &field.Error{
+			Type:     field.ErrorTypeRequired,
+			Field:    "spec.monitor.exporter.bindPassword",
+			BadValue: r.GetExporter().BindPassword,
+			Detail:   "If exporter bind dn provided, bind password must be provided",
+		}
+	}
+
+	return nil
+}
+
+func (r *OpenldapCluster) validateElection() field.ErrorList {
+	apierrs := field.ErrorList{}
+
+	electable := 0
+	for i := 0; i < r.GetReplicas(); i++ {
+		if r.IsElectable(i) {
+			electable++
+		}
+	}
+
+	if electable == 0 {
+		apierrs = append(apierrs, &field.Error{
+			Type:     field.ErrorTypeForbidden,
+			Field:    "spec.election.excludedOrdinals",
+			BadValue: r.Spec.Election.ExcludedOrdinals,
+			Detail:   "At least one pod must be able to become master",
+		})
+	}
+
+	if r.FencingEnabled() && r.Spec.OpenldapConfig.ConfigPassword == nil {
+		apierrs = append(apierrs, &field.Error{
+			Type:     field.ErrorTypeRequired,
+			Field:    "spec.openldapConfig.configPassword",
+			BadValue: r.Spec.OpenldapConfig.ConfigPassword,
+			Detail:   "If fencing enabled, config password must be provided",
+		})
+	}
+
+	preferred := r.PreferredMasterIndex()
+	if preferred < 0 {
+		return apierrs
+	}
+
+	if preferred >= r.GetReplicas() {
+		apierrs = append(apierrs, &field.Error{
+			Type:     field.ErrorTypeInvalid,
+			Field:    "spec.election.preferredMaster",
+			BadValue: preferred,
+			Detail:   "Preferred master must be less than replicas",
+		})
+	}
+
+	if !r.IsElectable(preferred) {
+		apierrs = append(apierrs, &field.Error{
+			Type:     field.ErrorTypeForbidden,
+			Field:    "spec.election.preferredMaster",
+			BadValue: preferred,
+			Detail:   "Preferred master cannot be one of excluded ordinals",
+		})
+	}
+
+	return apierrs
+}
+
+func (r *OpenldapCluster) validateReplication() *field.Error {
+	if r.Spec.Replication.ConfigDatabase && !r.ReplicationManaged() {
+		return &field.Error{
+			Type:     field.ErrorTypeForbidden,
+			Field:    "spec.replication.configDatabase",
+			BadValue: r.Spec.Replication.ConfigDatabase,
+			Detail:   "Config database is only replicated when replication is enabled or with multiple providers",
+		}
+	}
+
+	if r.ReplicationEnabled() && r.Spec.OpenldapConfig.ConfigPassword == nil {
+		return &field.Error{
+			Type:     field.ErrorTypeRequired,
+			Field:    "spec.openldapConfig.configPassword",
+			BadValue: r.Spec.OpenldapConfig.ConfigPassword,
+			Detail:   "If replication enabled, config password must be provided",
+		}
+	}
+
+	return nil
+}
+
+func (r *OpenldapCluster) validateAutoscaling() field.ErrorList {
+	apierrs := field.ErrorList{}
+
+	if !r.AutoscalingEnabled() {
+		return apierrs
+	}
+
+	autoscaling := r.GetAutoscaling()
+	if autoscaling.HighAvailability && *autoscaling.MinReplicas < 2 {
+		apierrs = append(apierrs, &field.Error{
+			Type:     field.ErrorTypeInvalid,
+			Field:    "spec.autoscaling.minReplicas",
+			BadValue: *autoscaling.MinReplicas,
+			Detail:   "Min replicas must be at least 2 if high availability is requested",
+		})
+	}
+
+	if autoscaling.MaxReplicas < *autoscaling.MinReplicas {
+		apierrs = append(apierrs, &field.Error{
+			Type:     field.ErrorTypeInvalid,
+			Field:    "spec.autoscaling.maxReplicas",
+			BadValue: autoscaling.MaxReplicas,
+			Detail:   "Max replicas must not be less than min replicas",
+		})
+	}
+
+	if autoscaling.TargetCPUUtilizationPercentage == nil && autoscaling.SearchRate == nil {
+		apierrs = append(apierrs, &field.Error{
+			Type:     field.ErrorTypeRequired,
+			Field:    "spec.autoscaling",
+			BadValue: nil,
+			Detail:   "One of targetCPUUtilizationPercentage and searchRate must be provided",
+		})
+	}
+
+	if autoscaling.SearchRate != nil && !r.ExporterEnabled() {
+		apierrs = append(apierrs, &field.Error{
+			Type:     field.ErrorTypeForbidden,
+			Field:    "spec.autoscaling.searchRate",
+			BadValue: autoscaling.SearchRate.Metric,
+			Detail:   "Search rate is scraped from the exporter, exporter monitor must be enabled",
+		})
+	}
+
+	return apierrs
+}
+
+func (r *OpenldapCluster) validateTopology() *field.Error {
+	if r.IsMultiProvider() && r.GetReplicas() < 2 {
+		return &field.Error{
+			Type:     field.ErrorTypeForbidden,
+			Field:    "spec.topology.mode",
+			BadValue: r.GetTopologyMode(),
+			Detail:   "Multiple providers require at least 2 replicas",
+		}
+	}
+
+	if r.IsMultiProvider() && r.Spec.OpenldapConfig.ConfigPassword == nil {
+		return &field.Error{
+			Type:     field.ErrorTypeRequired,
+			Field:    "spec.openldapConfig.configPassword",
+			BadValue: r.Spec.OpenldapConfig.ConfigPassword,
+			Detail:   "If multiple providers, config password must be provided",
+		}
+	}
+
+	return nil
+}
+
+func (r *OpenldapCluster) validateTopologyChanged(old *OpenldapCluster) *field.Error {
+	if old.GetTopologyMode() != r.GetTopologyMode() {
+		return &field.Error{
+			Type:     field.ErrorTypeForbidden,
+			Field:    "spec.topology.mode",
+			BadValue: r.GetTopologyMode(),
+			Detail:   "Cannot change topology after created",
+		}
+	}
+
+	return nil
+}
+
+func (r *OpenldapCluster) validateReplica() field.ErrorList {
+	apierrs := field.ErrorList{}
+
+	if !r.ReplicaEnabled() {
+		return apierrs
+	}
+
+	if r.Spec.Replica.Source == nil {
+		return append(apierrs, &field.Error{
+			Type:     field.ErrorTypeRequired,
+			Field:    "spec.replica.source",
+			BadValue: r.Spec.Replica.Source,
+			Detail:   "If replica enabled, source must be provided",
+		})
+	}
+
+	source := r.Spec.Replica.Source
+	if (source.Url == "") == (source.Cluster == nil) {
+		apierrs = append(apierrs, &field.Error{
+			Type:     field.ErrorTypeInvalid,
+			Field:    "spec.replica.source",
+			BadValue: source.Url,
+			Detail:   "Exactly one of url and cluster must be provided",
+		})
+	}
+
+	if source.Cluster != nil &&
+		source.Cluster.Name == r.Name &&
+		(source.Cluster.Namespace == "" || source.Cluster.Namespace == r.Namespace) {
+		apierrs = append(apierrs, &field.Error{
+			Type:     field.ErrorTypeForbidden,
+			Field:    "spec.replica.source.cluster",
+			BadValue: source.Cluster.Name,
+			Detail:   "Cluster cannot replicate from itself",
+		})
+	}
+
+	if r.Spec.Replica.Source.BindPassword == nil {
+		apierrs = append(apierrs, &field.Error{
+			Type:     field.ErrorTypeRequired,
+			Field:    "spec.replica.source.bindPassword",
+			BadValue: r.Spec.Replica.Source.BindPassword,
+			Detail:   "If replica enabled, bind password must be provided",
+		})
+	}
+
+	return apierrs
+}
+
+func (r *OpenldapCluster) validateReplicaChanged(old *OpenldapCluster) *field.Error {
+	if !old.ReplicaEnabled() && r.ReplicaEnabled() {
+		return &field.Error{
+			Type:     field.ErrorTypeForbidden,
+			Field:    "spec.replica.enabled",
+			BadValue: r.ReplicaEnabled(),
+			Detail:   "Cannot turn a cluster into replica after created, replica can only be promoted",
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,290 @@
+package v1_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/types"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	openldapv1 "github.com/qwp0905/openldap-operator/api/v1"
+	"github.com/qwp0905/openldap-operator/internal/fixtures"
+)
+
+// rejectField matches a validation error with a cause on the field.
+func rejectField(field string) types.GomegaMatcher {
+	return WithTransform(func(err error) []string {
+		fields := []string{}
+		if status, ok := err.(*errors.StatusError); ok {
+			for _, cause := range status.ErrStatus.Details.Causes {
+				fields = append(fields, cause.Field)
+			}
+		}
+		return fields
+	}, ContainElement(field))
+}
+
+var _ = Describe("OpenldapCluster Webhook", func() {
+	It("accepts a defaulted cluster", func() {
+		Expect(fixtures.NewCluster("ldap", 3).Validate()).To(Succeed())
+	})
+
+	Context("tls options", func() {
+		It("rejects requireTls and ldapsOnly without tls", func() {
+			cluster := fixtures.NewCluster("ldap", 3)
+			cluster.Spec.OpenldapConfig.Tls.RequireTls = true
+			cluster.Spec.OpenldapConfig.Tls.LdapsOnly = true
+
+			err := cluster.Validate()
+			Expect(err).To(rejectField("spec.openldapConfig.tls.requireTls"))
+			Expect(err).To(rejectField("spec.openldapConfig.tls.ldapsOnly"))
+		})
+
+		It("accepts requireTls and ldapsOnly with tls", func() {
+			cluster := fixtures.WithTls(fixtures.NewCluster("ldap", 3))
+			cluster.Spec.OpenldapConfig.Tls.RequireTls = true
+			Expect(cluster.Validate()).To(Succeed())
+
+			cluster.Spec.OpenldapConfig.Tls.LdapsOnly = true
+			Expect(cluster.Validate()).To(Succeed())
+		})
+	})
+
+	Context("services", func() {
+		It("rejects external options on a ClusterIP service", func() {
+			cluster := fixtures.NewCluster("ldap", 3)
+			lbClass := "service.k8s.aws/nlb"
+			cluster.Spec.Services.Read.NodePorts = map[string]int32{"ldap": 30389}
+			cluster.Spec.Services.Read.ExternalTrafficPolicy = corev1.ServiceExternalTrafficPolicyTypeLocal
+			cluster.Spec.Services.Write.LoadBalancerClass = &lbClass
+
+			err := cluster.Validate()
+			Expect(err).To(rejectField("spec.services.read.nodePorts"))
+			Expect(err).To(rejectField("spec.services.read.externalTrafficPolicy"))
+			Expect(err).To(rejectField("spec.services.write.type"))
+		})
+
+		It("accepts node ports on a NodePort service", func() {
+			cluster := fixtures.NewCluster("ldap", 3)
+			cluster.Spec.Services.Read.Type = corev1.ServiceTypeNodePort
+			cluster.Spec.Services.Read.NodePorts = map[string]int32{"ldap": 30389}
+
+			Expect(cluster.Validate()).To(Succeed())
+		})
+	})
+
+	Context("exporter", func() {
+		It("rejects a bind dn without password", func() {
+			cluster := fixtures.NewCluster("ldap", 3)
+			cluster.Spec.Monitor.Exporter.BindDn = "cn=monitor,dc=example,dc=com"
+
+			Expect(cluster.Validate()).To(rejectField("spec.monitor.exporter.bindPassword"))
+		})
+
+		It("accepts a bind dn with password", func() {
+			cluster := fixtures.NewCluster("ldap", 3)
+			cluster.Spec.Monitor.Exporter.BindDn = "cn=monitor,dc=example,dc=com"
+			cluster.Spec.Monitor.Exporter.BindPassword = &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "monitor"},
+				Key:                  "password",
+			}
+
+			Expect(cluster.Validate()).To(Succeed())
+		})
+	})
+
+	Context("autoscaling", func() {
+		It("requires a metric and max replicas above min replicas", func() {
+			cluster := fixtures.NewCluster("ldap", 3)
+			cluster.Spec.Autoscaling.Enabled = true
+			cluster.Spec.Autoscaling.MaxReplicas = 1
+
+			err := cluster.Validate()
+			Expect(err).To(rejectField("spec.autoscaling"))
+			Expect(err).To(rejectField("spec.autoscaling.maxReplicas"))
+
+			cpu := int32(70)
+			cluster.Spec.Autoscaling.TargetCPUUtilizationPercentage = &cpu
+			cluster.Spec.Autoscaling.MaxReplicas = 5
+			Expect(cluster.Validate()).To(Succeed())
+		})
+
+		It("rejects a search rate without the exporter", func() {
+			cluster := fixtures.NewCluster("ldap", 3)
+			cluster.Spec.Autoscaling.Enabled = true
+			cluster.Spec.Autoscaling.MaxReplicas = 5
+			cluster.Spec.Autoscaling.SearchRate = &openldapv1.SearchRateTarget{
+				Metric:       "openldap_search_operations_per_second",
+				AverageValue: resource.MustParse("500"),
+			}
+
+			Expect(cluster.Validate()).To(rejectField("spec.autoscaling.searchRate"))
+		})
+	})
+
+	Context("storage", func() {
+		It("rejects shrinking the storage", func() {
+			old := fixtures.NewCluster("ldap", 3)
+
+			cluster := fixtures.NewCluster("ldap", 3)
+			cluster.Spec.Storage.VolumeClaimTemplate.Resources.Requests[corev1.ResourceStorage] = resource.MustParse("4Gi")
+			Expect(cluster.ValidateChange(old)).To(rejectField("spec.storage.volumeClaimTemplate.resources.requests.storage"))
+
+			cluster.Spec.Storage.VolumeClaimTemplate.Resources.Requests[corev1.ResourceStorage] = resource.MustParse("16Gi")
+			Expect(cluster.ValidateChange(old)).To(Succeed())
+		})
+
+		It("rejects migrating the storage class of a single replica or without operator monitor", func() {
+			storageClass := "fast"
+			old := fixtures.NewCluster("ldap", 3)
+
+			cluster := fixtures.NewCluster("ldap", 3)
+			cluster.Spec.Storage.VolumeClaimTemplate.StorageClassName = &storageClass
+			Expect(cluster.ValidateChange(old)).To(rejectField("spec.storage.volumeClaimTemplate.storageClassName"))
+
+			cluster.Spec.Monitor.Enabled = true
+			cluster.Spec.Monitor.Mode = openldapv1.MonitorModeOperator
+			Expect(cluster.ValidateChange(old)).To(Succeed())
+
+			cluster.Spec.Replicas = 1
+			Expect(cluster.ValidateChange(old)).To(rejectField("spec.storage.volumeClaimTemplate.storageClassName"))
+		})
+	})
+
+	Context("pod disruption budget", func() {
+		It("rejects both minAvailable and maxUnavailable", func() {
+			cluster := fixtures.NewCluster("ldap", 3)
+			minAvailable := intstr.FromInt(2)
+			cluster.Spec.PodDisruptionBudget.MinAvailable = &minAvailable
+
+			Expect(cluster.Validate()).To(rejectField("spec.podDisruptionBudget.minAvailable"))
+		})
+	})
+
+	Context("election", func() {
+		It("rejects excluding every pod", func() {
+			cluster := fixtures.NewCluster("ldap", 2)
+			cluster.Spec.Election.ExcludedOrdinals = []int32{0, 1}
+
+			Expect(cluster.Validate()).To(rejectField("spec.election.excludedOrdinals"))
+		})
+
+		It("rejects an excluded or missing preferred master", func() {
+			cluster := fixtures.NewCluster("ldap", 3)
+			preferred := int32(3)
+			cluster.Spec.Election.PreferredMaster = &preferred
+			Expect(cluster.Validate()).To(rejectField("spec.election.preferredMaster"))
+
+			preferred = 1
+			cluster.Spec.Election.ExcludedOrdinals = []int32{1}
+			Expect(cluster.Validate()).To(rejectField("spec.election.preferredMaster"))
+		})
+	})
+
+	Context("topology", func() {
+		It("rejects multiple providers with a single replica", func() {
+			cluster := fixtures.WithTopology(fixtures.NewCluster("ldap", 1), openldapv1.TopologyMirrorMode)
+
+			Expect(cluster.Validate()).To(rejectField("spec.topology.mode"))
+		})
+
+		It("rejects multiple providers without config password", func() {
+			cluster := fixtures.WithTopology(fixtures.NewCluster("ldap", 3), openldapv1.TopologyMultiProvider)
+			cluster.Spec.OpenldapConfig.ConfigPassword = nil
+
+			Expect(cluster.Validate()).To(rejectField("spec.openldapConfig.configPassword"))
+		})
+
+		It("rejects changing the topology", func() {
+			old := fixtures.NewCluster("ldap", 3)
+			cluster := fixtures.WithTopology(old.DeepCopy(), openldapv1.TopologyMultiProvider)
+
+			Expect(cluster.Validate()).To(Succeed())
+			Expect(cluster.ValidateChange(old)).To(rejectField("spec.topology.mode"))
+		})
+	})
+
+	Context("replica", func() {
+		It("rejects a replica without source", func() {
+			cluster := fixtures.NewCluster("ldap", 3)
+			cluster.Spec.Replica = &openldapv1.ReplicaConfig{Enabled: true}
+
+			Expect(cluster.Validate()).To(rejectField("spec.replica.source"))
+		})
+
+		It("rejects a source without bind password", func() {
+			cluster := fixtures.WithReplica(fixtures.NewCluster("ldap", 3), "ldap://ldap.example.com")
+			cluster.Spec.Replica.Source.BindPassword = nil
+
+			Expect(cluster.Validate()).To(rejectField("spec.replica.source.bindPassword"))
+		})
+
+		It("rejects both or neither of url and cluster", func() {
+			cluster := fixtures.WithReplica(fixtures.NewCluster("ldap", 3), "ldap://ldap.example.com")
+			cluster.Spec.Replica.Source.Cluster = &openldapv1.ClusterReference{Name: "source"}
+			Expect(cluster.Validate()).To(rejectField("spec.replica.source"))
+
+			cluster.Spec.Replica.Source.Cluster = nil
+			cluster.Spec.Replica.Source.Url = ""
+			Expect(cluster.Validate()).To(rejectField("spec.replica.source"))
+		})
+
+		It("rejects replicating from itself", func() {
+			cluster := fixtures.WithReplica(fixtures.NewCluster("ldap", 3), "")
+			cluster.Spec.Replica.Source.Cluster = &openldapv1.ClusterReference{Name: "ldap"}
+			Expect(cluster.Validate()).To(rejectField("spec.replica.source.cluster"))
+
+			cluster.Spec.Replica.Source.Cluster.Namespace = "dr"
+			Expect(cluster.Validate()).To(Succeed())
+		})
+
+		It("only promotes a replica after created", func() {
+			old := fixtures.NewCluster("ldap", 3)
+			replica := fixtures.WithReplica(old.DeepCopy(), "ldap://ldap.example.com")
+			Expect(replica.ValidateChange(old)).To(rejectField("spec.replica.enabled"))
+
+			promoted := replica.DeepCopy()
+			promoted.Spec.Replica.Enabled = false
+			Expect(promoted.ValidateChange(replica)).To(Succeed())
+		})
+	})
+
+	Context("replication", func() {
+		It("rejects replication without config password", func() {
+			cluster := fixtures.NewCluster("ldap", 3)
+			cluster.Spec.Replication.Enabled = true
+			Expect(cluster.Validate()).To(Succeed())
+
+			cluster.Spec.OpenldapConfig.ConfigPassword = nil
+			Expect(cluster.Validate()).To(rejectField("spec.openldapConfig.configPassword"))
+		})
+
+		It("rejects config database replication not managed by the operator", func() {
+			cluster := fixtures.NewCluster("ldap", 3)
+			cluster.Spec.Replication.ConfigDatabase = true
+			Expect(cluster.Validate()).To(rejectField("spec.replication.configDatabase"))
+
+			cluster.Spec.Replication.Enabled = true
+			Expect(cluster.Validate()).To(Succeed())
+		})
+	})
+
+	Context("scale", func() {
+		It("warns on scaling down to a single replica", func() {
+			validator := &openldapv1.OpenldapClusterValidator{}
+			old := fixtures.NewCluster("ldap", 3)
+
+			warnings, err := validator.ValidateUpdate(context.Background(), old, fixtures.NewCluster("ldap", 2))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(warnings).To(BeEmpty())
+
+			warnings, err = validator.ValidateUpdate(context.Background(), old, fixtures.NewCluster("ldap", 1))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(warnings).To(HaveLen(1))
+		})
+	})
+})
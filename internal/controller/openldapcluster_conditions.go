@@ -0,0 +1,344 @@
+package controller
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	openldapv1 "github.com/qwp0905/openldap-operator/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// updateConditions refreshes the conditions derived from the observed state of
+// the cluster and returns whether any of them or the replication status
+// changed.
+func (r *OpenldapClusterReconciler) updateConditions(
+	ctx context.Context,
+	cluster *openldapv1.OpenldapCluster,
+) (bool, error) {
+	origin := cluster.Status.DeepCopy()
+
+	refreshElectionConditions(cluster)
+
+	if err := r.updateProgressingCondition(ctx, cluster); err != nil {
+		return false, err
+	}
+
+	r.updateDegradedCondition(cluster)
+	r.updateReplicationLag(cluster)
+	r.updateConfigReplicationStatus(cluster)
+	r.updateReplicationCondition(cluster)
+	r.updateConfigReplicatedCondition(cluster)
+
+	if err := r.updateTlsCondition(ctx, cluster); err != nil {
+		return false, err
+	}
+
+	return !reflect.DeepEqual(origin.Conditions, cluster.Status.Conditions) ||
+		!reflect.DeepEqual(origin.Replication, cluster.Status.Replication) ||
+		!reflect.DeepEqual(origin.ConfigReplication, cluster.Status.ConfigReplication), nil
+}
+
+// refreshElectionConditions keeps the observedGeneration of the conditions set
+// by the election up to date, they are otherwise only set on transitions.
+func refreshElectionConditions(cluster *openldapv1.OpenldapCluster) {
+	for _, conditionType := range []string{
+		openldapv1.ConditionInitialized,
+		openldapv1.ConditionReady,
+		openldapv1.ConditionElected,
+	} {
+		condition := cluster.GetCondition(conditionType)
+		if condition == nil {
+			continue
+		}
+
+		cluster.SetConditionStatus(conditionType, condition.Status, condition.Reason, condition.Message)
+	}
+}
+
+func (r *OpenldapClusterReconciler) updateProgressingCondition(
+	ctx context.Context,
+	cluster *openldapv1.OpenldapCluster,
+) error {
+	switch cluster.GetPhase() {
+	case openldapv1.PhaseInitializing:
+		cluster.SetConditionProgressing(
+			true,
+			openldapv1.ReasonClusterCreated,
+			"Cluster is initializing",
+		)
+		return nil
+	case openldapv1.PhaseFailingOver, openldapv1.PhaseSwitchover:
+		cluster.SetConditionProgressing(
+			true,
+			openldapv1.ReasonElectionInProgress,
+			fmt.Sprintf("Electing %s as master", cluster.GetDesiredMaster()),
+		)
+		return nil
+	}
+
+	statefulset, err := r.getStatefulset(ctx, cluster)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return err
+		}
+
+		cluster.SetConditionProgressing(
+			true,
+			openldapv1.ReasonClusterCreated,
+			"Waiting for statefulset to be created",
+		)
+		return nil
+	}
+
+	if statefulset.Status.UpdatedReplicas < cluster.Spec.Replicas ||
+		statefulset.Status.CurrentRevision != statefulset.Status.UpdateRevision {
+		cluster.SetConditionProgressing(
+			true,
+			openldapv1.ReasonRollingUpdate,
+			fmt.Sprintf(
+				"%d/%d replicas updated to revision %s",
+				statefulset.Status.UpdatedReplicas,
+				cluster.Spec.Replicas,
+				statefulset.Status.UpdateRevision,
+			),
+		)
+		return nil
+	}
+
+	cluster.SetConditionProgressing(
+		false,
+		openldapv1.ReasonReconciled,
+		"Cluster is up to date",
+	)
+	return nil
+}
+
+func (r *OpenldapClusterReconciler) updateDegradedCondition(cluster *openldapv1.OpenldapCluster) {
+	message := fmt.Sprintf("%s replicas ready", cluster.Status.Ready)
+
+	if cluster.Status.ReadyReplicas < cluster.Spec.Replicas {
+		cluster.SetConditionDegraded(true, openldapv1.ReasonReplicasNotReady, message)
+		return
+	}
+
+	cluster.SetConditionDegraded(false, openldapv1.ReasonReplicasReady, message)
+}
+
+// updateReplicationCondition compares the contextCSN of every server id
+// scraped from each pod with the newest one among the pods, so that both
+// lagging consumers and diverged providers are reported. It is only available
+// when the operator monitor mode is enabled.
+func (r *OpenldapClusterReconciler) updateReplicationCondition(cluster *openldapv1.OpenldapCluster) {
+	if cluster.ReplicaEnabled() {
+		updateReplicaSourceCondition(cluster)
+		return
+	}
+
+	podMonitors, ok := clusterMonitor.load(clusterKey(cluster))
+	if !cluster.NativeMonitorEnabled() || !ok {
+		cluster.SetConditionStatus(
+			openldapv1.ConditionReplicationHealthy,
+			metav1.ConditionUnknown,
+			openldapv1.ReasonReplicationUnknown,
+			"Replication is only observed in operator monitor mode",
+		)
+		return
+	}
+
+	newest := map[string]time.Time{}
+	for _, monitor := range podMonitors {
+		if monitor.snapshot == nil {
+			continue
+		}
+
+		for serverId, changedAt := range monitor.snapshot.ContextCsn {
+			if changedAt.After(newest[serverId]) {
+				newest[serverId] = changedAt
+			}
+		}
+	}
+
+	if len(newest) == 0 {
+		cluster.SetConditionStatus(
+			openldapv1.ConditionReplicationHealthy,
+			metav1.ConditionUnknown,
+			openldapv1.ReasonReplicationUnknown,
+			"cn=Monitor of pods is not available",
+		)
+		return
+	}
+
+	for pod, monitor := range podMonitors {
+		if monitor.snapshot == nil {
+			continue
+		}
+
+		for serverId, changedAt := range newest {
+			if changedAt.Sub(monitor.snapshot.ContextCsn[serverId]) > cluster.MaxReplicationLag() {
+				cluster.SetCondition(
+					openldapv1.ConditionReplicationHealthy,
+					false,
+					openldapv1.ReasonReplicationLagging,
+					fmt.Sprintf("Pod %s is behind on changes of server id %s", pod, serverId),
+				)
+				return
+			}
+		}
+	}
+
+	cluster.SetCondition(
+		openldapv1.ConditionReplicationHealthy,
+		true,
+		openldapv1.ReasonReplicationInSync,
+		"All pods are in sync",
+	)
+}
+
+// updateReplicaSourceCondition reports the lag of a replica cluster behind
+// its source, measured by updateReplicationLag.
+func updateReplicaSourceCondition(cluster *openldapv1.OpenldapCluster) {
+	replication := cluster.GetReplicationStatus()
+	if replication.LagSeconds == nil {
+		cluster.SetConditionStatus(
+			openldapv1.ConditionReplicationHealthy,
+			metav1.ConditionUnknown,
+			openldapv1.ReasonReplicationUnknown,
+			fmt.Sprintf("contextCSN of source %s or pods is not available", replication.Source),
+		)
+		return
+	}
+
+	if time.Duration(*replication.LagSeconds)*time.Second > cluster.MaxReplicationLag() {
+		cluster.SetCondition(
+			openldapv1.ConditionReplicationHealthy,
+			false,
+			openldapv1.ReasonReplicationLagging,
+			fmt.Sprintf("Pods are %ds behind source %s", *replication.LagSeconds, replication.Source),
+		)
+		return
+	}
+
+	cluster.SetCondition(
+		openldapv1.ConditionReplicationHealthy,
+		true,
+		openldapv1.ReasonReplicationInSync,
+		fmt.Sprintf("All pods are in sync with source %s", replication.Source),
+	)
+}
+
+func (r *OpenldapClusterReconciler) updateConfigReplicatedCondition(cluster *openldapv1.OpenldapCluster) {
+	status := cluster.Status.ConfigReplication
+	if status == nil {
+		cluster.RemoveCondition(openldapv1.ConditionConfigReplicated)
+		return
+	}
+
+	if len(status.PendingPods) > 0 {
+		cluster.SetCondition(
+			openldapv1.ConditionConfigReplicated,
+			false,
+			openldapv1.ReasonConfigNotConverged,
+			fmt.Sprintf("cn=config of %s not converged", strings.Join(status.PendingPods, ", ")),
+		)
+		return
+	}
+
+	cluster.SetCondition(
+		openldapv1.ConditionConfigReplicated,
+		true,
+		openldapv1.ReasonConfigConverged,
+		"cn=config of all pods converged",
+	)
+}
+
+func (r *OpenldapClusterReconciler) updateTlsCondition(
+	ctx context.Context,
+	cluster *openldapv1.OpenldapCluster,
+) error {
+	if !cluster.TlsEnabled() {
+		cluster.RemoveCondition(openldapv1.ConditionTlsReady)
+		return nil
+	}
+
+	tlsConfig := cluster.Spec.OpenldapConfig.Tls
+	secret := &corev1.Secret{}
+
+	if err := r.Get(
+		ctx,
+		types.NamespacedName{Name: tlsConfig.SecretName, Namespace: cluster.Namespace},
+		secret,
+	); err != nil {
+		if !errors.IsNotFound(err) {
+			return err
+		}
+
+		cluster.SetConditionTlsReady(
+			false,
+			openldapv1.ReasonTlsSecretNotFound,
+			fmt.Sprintf("Secret %s not found", tlsConfig.SecretName),
+		)
+		return nil
+	}
+
+	for _, key := range []string{tlsConfig.CertFile, tlsConfig.KeyFile, tlsConfig.CaFile} {
+		if _, ok := secret.Data[key]; !ok {
+			cluster.SetConditionTlsReady(
+				false,
+				openldapv1.ReasonTlsSecretKeyNotFound,
+				fmt.Sprintf("Key %s not found in secret %s", key, tlsConfig.SecretName),
+			)
+			return nil
+		}
+	}
+
+	if missing := missingCertificateSans(secret.Data[tlsConfig.CertFile], cluster.TlsDnsNames()); len(missing) > 0 {
+		cluster.SetConditionTlsReady(
+			false,
+			openldapv1.ReasonTlsSanMissing,
+			fmt.Sprintf(
+				"Certificate in secret %s does not cover %s",
+				tlsConfig.SecretName,
+				strings.Join(missing, ", "),
+			),
+		)
+		return nil
+	}
+
+	cluster.SetConditionTlsReady(
+		true,
+		openldapv1.ReasonTlsSecretFound,
+		fmt.Sprintf("Secret %s found", tlsConfig.SecretName),
+	)
+	return nil
+}
+
+// missingCertificateSans returns the names not covered by the leaf
+// certificate, every name is missing if the certificate cannot be parsed.
+func missingCertificateSans(certPem []byte, names []string) []string {
+	block, _ := pem.Decode(certPem)
+	if block == nil {
+		return names
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return names
+	}
+
+	missing := []string{}
+	for _, name := range names {
+		if err = cert.VerifyHostname(name); err != nil {
+			missing = append(missing, name)
+		}
+	}
+
+	return missing
+}
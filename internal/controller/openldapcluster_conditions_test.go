@@ -0,0 +1,131 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	openldapv1 "github.com/qwp0905/openldap-operator/api/v1"
+	"github.com/qwp0905/openldap-operator/internal/fixtures"
+)
+
+// monitoredCluster returns a cluster in operator monitor mode with ldap-0
+// elected, the snapshot of ldap-1 is behind the others by the lag.
+func monitoredCluster(lag time.Duration) *openldapv1.OpenldapCluster {
+	cluster := fixtures.NewCluster("ldap", 3)
+	cluster.Spec.Monitor.Enabled = true
+	cluster.Spec.Monitor.Mode = openldapv1.MonitorModeOperator
+	cluster.SetDefault()
+	cluster.UpdateDesiredMaster(0)
+	cluster.UpdateCurrentMaster()
+
+	now := time.Now()
+	storeTestSnapshots(cluster, now, now.Add(-lag), now)
+
+	return cluster
+}
+
+var _ = Describe("Cluster conditions", func() {
+	ctx := context.Background()
+
+	It("keeps the observedGeneration of the election conditions up to date", func() {
+		reconciler, _ := newTestReconciler()
+		cluster := createTestCluster(ctx, 3)
+		cluster.SetInitCondition()
+
+		cluster.Generation = 2
+		_, err := reconciler.updateConditions(ctx, cluster)
+		Expect(err).NotTo(HaveOccurred())
+
+		for _, conditionType := range []string{
+			openldapv1.ConditionInitialized,
+			openldapv1.ConditionReady,
+			openldapv1.ConditionElected,
+		} {
+			Expect(cluster.GetCondition(conditionType).ObservedGeneration).To(Equal(int64(2)))
+		}
+	})
+
+	Context("replication", func() {
+		It("is unknown without the operator monitor mode", func() {
+			cluster := fixtures.NewCluster("ldap", 3)
+			(&OpenldapClusterReconciler{}).updateReplicationCondition(cluster)
+
+			condition := cluster.GetCondition(openldapv1.ConditionReplicationHealthy)
+			Expect(condition.Status).To(Equal(metav1.ConditionUnknown))
+		})
+
+		It("tolerates the lag of the alert rules", func() {
+			cluster := monitoredCluster(30 * time.Second)
+			cluster.Spec.Monitor.Rules = &openldapv1.MonitorRulesConfig{ReplicationLagSeconds: 20}
+
+			(&OpenldapClusterReconciler{}).updateReplicationCondition(cluster)
+			condition := cluster.GetCondition(openldapv1.ConditionReplicationHealthy)
+			Expect(condition.Reason).To(Equal(openldapv1.ReasonReplicationLagging))
+
+			cluster.Spec.Monitor.Rules.ReplicationLagSeconds = 60
+			(&OpenldapClusterReconciler{}).updateReplicationCondition(cluster)
+			condition = cluster.GetCondition(openldapv1.ConditionReplicationHealthy)
+			Expect(condition.Reason).To(Equal(openldapv1.ReasonReplicationInSync))
+		})
+
+		It("reports a master behind another provider", func() {
+			cluster := monitoredCluster(0)
+			now := time.Now()
+			storeTestSnapshots(cluster, now.Add(-2*time.Minute), now, now)
+
+			(&OpenldapClusterReconciler{}).updateReplicationCondition(cluster)
+			condition := cluster.GetCondition(openldapv1.ConditionReplicationHealthy)
+			Expect(condition.Reason).To(Equal(openldapv1.ReasonReplicationLagging))
+			Expect(condition.Message).To(ContainSubstring(cluster.PodName(0)))
+		})
+	})
+
+	Context("tls", func() {
+		It("reports the names not covered by the certificate", func() {
+			reconciler, _ := newTestReconciler()
+			cluster := fixtures.WithTls(createTestCluster(ctx, 3))
+
+			Expect(reconciler.updateTlsCondition(ctx, cluster)).To(Succeed())
+			Expect(cluster.GetCondition(openldapv1.ConditionTlsReady).Reason).
+				To(Equal(openldapv1.ReasonTlsSecretNotFound))
+
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      cluster.Spec.OpenldapConfig.Tls.SecretName,
+					Namespace: cluster.Namespace,
+				},
+				Data: map[string][]byte{
+					cluster.Spec.OpenldapConfig.Tls.CertFile: newTestCertificate(cluster.PodDnsName(0)),
+					cluster.Spec.OpenldapConfig.Tls.KeyFile:  []byte("key"),
+					cluster.Spec.OpenldapConfig.Tls.CaFile:   newTestCertificate(),
+				},
+			}
+			Expect(k8sClient.Create(ctx, secret)).To(Succeed())
+
+			Expect(reconciler.updateTlsCondition(ctx, cluster)).To(Succeed())
+			condition := cluster.GetCondition(openldapv1.ConditionTlsReady)
+			Expect(condition.Reason).To(Equal(openldapv1.ReasonTlsSanMissing))
+			Expect(condition.Message).To(ContainSubstring(cluster.PodDnsName(1)))
+			Expect(condition.Message).NotTo(ContainSubstring(cluster.PodDnsName(0)))
+
+			secret.Data[cluster.Spec.OpenldapConfig.Tls.CertFile] = newTestCertificate("*." + cluster.HeadlessServiceName() + ".default.svc.cluster.local")
+			Expect(k8sClient.Update(ctx, secret)).To(Succeed())
+
+			Expect(reconciler.updateTlsCondition(ctx, cluster)).To(Succeed())
+			Expect(cluster.GetCondition(openldapv1.ConditionTlsReady).Status).
+				To(Equal(metav1.ConditionTrue))
+		})
+
+		It("reports every name if the certificate cannot be parsed", func() {
+			cluster := fixtures.NewCluster("ldap", 3)
+
+			Expect(missingCertificateSans([]byte("not a certificate"), cluster.TlsDnsNames())).
+				To(Equal(cluster.TlsDnsNames()))
+		})
+	})
+})
@@ -28,6 +28,7 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
 
 	openldapv1 "github.com/qwp0905/openldap-operator/api/v1"
 )
@@ -37,6 +38,10 @@ type OpenldapClusterReconciler struct {
 	client.Client
 	Scheme   *runtime.Scheme
 	Recorder record.EventRecorder
+
+	// OperatorNamespace is allowed by the network policies unless the
+	// cluster names another one.
+	OperatorNamespace string
 }
 
 //+kubebuilder:rbac:groups=openldap.kwonjin.click,resources=openldapclusters,verbs=get;list;watch;create;update;patch;delete
@@ -57,15 +62,35 @@ func (r *OpenldapClusterReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 		return ctrl.Result{}, err
 	}
 	if cluster == nil {
+		deleteClusterMetrics(req.NamespacedName)
+		return ctrl.Result{}, nil
+	}
+
+	if !cluster.DeletionTimestamp.IsZero() {
+		if err = r.finalizeCluster(ctx, cluster); err != nil {
+			observeReconcileError(cluster, "finalizeCluster")
+			return ctrl.Result{}, err
+		}
 		return ctrl.Result{}, nil
 	}
 
 	if err = r.setDefault(ctx, cluster); err != nil {
+		observeReconcileError(cluster, "setDefault")
 		return ctrl.Result{}, err
 	}
 
-	requeue, err := r.ensureRole(ctx, cluster)
+	requeue, err := r.ensureFinalizer(ctx, cluster)
 	if err != nil {
+		observeReconcileError(cluster, "ensureFinalizer")
+		return ctrl.Result{}, err
+	}
+	if requeue {
+		return ctrl.Result{RequeueAfter: time.Second * 2}, nil
+	}
+
+	requeue, err = r.ensureRole(ctx, cluster)
+	if err != nil {
+		observeReconcileError(cluster, "ensureRole")
 		return ctrl.Result{}, err
 	}
 	if requeue {
@@ -74,6 +99,7 @@ func (r *OpenldapClusterReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 
 	requeue, err = r.ensureServiceAccount(ctx, cluster)
 	if err != nil {
+		observeReconcileError(cluster, "ensureServiceAccount")
 		return ctrl.Result{}, err
 	}
 	if requeue {
@@ -82,6 +108,7 @@ func (r *OpenldapClusterReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 
 	requeue, err = r.ensureRoleBinding(ctx, cluster)
 	if err != nil {
+		observeReconcileError(cluster, "ensureRoleBinding")
 		return ctrl.Result{}, err
 	}
 	if requeue {
@@ -90,6 +117,7 @@ func (r *OpenldapClusterReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 
 	requeue, err = r.ensureService(ctx, cluster)
 	if err != nil {
+		observeReconcileError(cluster, "ensureService")
 		return ctrl.Result{}, err
 	}
 	if requeue {
@@ -98,6 +126,52 @@ func (r *OpenldapClusterReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 
 	requeue, err = r.ensureServiceMonitor(ctx, cluster)
 	if err != nil {
+		observeReconcileError(cluster, "ensureServiceMonitor")
+		return ctrl.Result{}, err
+	}
+	if requeue {
+		return ctrl.Result{RequeueAfter: time.Second * 2}, nil
+	}
+
+	requeue, err = r.ensurePrometheusRule(ctx, cluster)
+	if err != nil {
+		observeReconcileError(cluster, "ensurePrometheusRule")
+		return ctrl.Result{}, err
+	}
+	if requeue {
+		return ctrl.Result{RequeueAfter: time.Second * 2}, nil
+	}
+
+	requeue, err = r.ensureNetworkPolicy(ctx, cluster)
+	if err != nil {
+		observeReconcileError(cluster, "ensureNetworkPolicy")
+		return ctrl.Result{}, err
+	}
+	if requeue {
+		return ctrl.Result{RequeueAfter: time.Second * 2}, nil
+	}
+
+	requeue, err = r.ensurePodDisruptionBudget(ctx, cluster)
+	if err != nil {
+		observeReconcileError(cluster, "ensurePodDisruptionBudget")
+		return ctrl.Result{}, err
+	}
+	if requeue {
+		return ctrl.Result{RequeueAfter: time.Second * 2}, nil
+	}
+
+	requeue, err = r.ensureHorizontalPodAutoscaler(ctx, cluster)
+	if err != nil {
+		observeReconcileError(cluster, "ensureHorizontalPodAutoscaler")
+		return ctrl.Result{}, err
+	}
+	if requeue {
+		return ctrl.Result{RequeueAfter: time.Second * 2}, nil
+	}
+
+	requeue, err = r.ensureReplicaSource(ctx, cluster)
+	if err != nil {
+		observeReconcileError(cluster, "ensureReplicaSource")
 		return ctrl.Result{}, err
 	}
 	if requeue {
@@ -106,6 +180,7 @@ func (r *OpenldapClusterReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 
 	requeue, err = r.ensureStatefulset(ctx, cluster)
 	if err != nil {
+		observeReconcileError(cluster, "ensureStatefulset")
 		return ctrl.Result{}, err
 	}
 	if requeue {
@@ -114,6 +189,7 @@ func (r *OpenldapClusterReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 
 	seconds, err := r.election(ctx, cluster)
 	if err != nil {
+		observeReconcileError(cluster, "election")
 		return ctrl.Result{}, err
 	}
 	if seconds != 0 {
@@ -171,6 +247,10 @@ func (r *OpenldapClusterReconciler) registerObject(
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *OpenldapClusterReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := mgr.Add(manager.RunnableFunc(r.observe)); err != nil {
+		return err
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&openldapv1.OpenldapCluster{}).
 		Complete(r)
@@ -0,0 +1,146 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	openldapv1 "github.com/qwp0905/openldap-operator/api/v1"
+	"github.com/qwp0905/openldap-operator/internal/fixtures"
+	"github.com/qwp0905/openldap-operator/pkg/monitors"
+)
+
+// newTestReconciler returns a reconciler on the test environment, recording
+// the events to be checked by the specs.
+func newTestReconciler() (*OpenldapClusterReconciler, *record.FakeRecorder) {
+	recorder := record.NewFakeRecorder(100)
+
+	return &OpenldapClusterReconciler{
+		Client:   k8sClient,
+		Scheme:   scheme.Scheme,
+		Recorder: recorder,
+	}, recorder
+}
+
+// createTestCluster creates a cluster with a unique name, so that the specs
+// do not see the objects of each other.
+func createTestCluster(ctx context.Context, replicas int32) *openldapv1.OpenldapCluster {
+	cluster := fixtures.NewCluster("ldap-"+rand.String(6), replicas)
+	Expect(k8sClient.Create(ctx, cluster)).To(Succeed())
+
+	return cluster
+}
+
+// getTestCluster reads the cluster back from the api server.
+func getTestCluster(ctx context.Context, cluster *openldapv1.OpenldapCluster) *openldapv1.OpenldapCluster {
+	updated := &openldapv1.OpenldapCluster{}
+	Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(cluster), updated)).To(Succeed())
+	updated.SetDefault()
+
+	return updated
+}
+
+// recordedReasons drains the recorded events and returns their reasons.
+func recordedReasons(recorder *record.FakeRecorder) []string {
+	reasons := []string{}
+	for {
+		select {
+		case event := <-recorder.Events:
+			reasons = append(reasons, strings.Fields(event)[1])
+		default:
+			return reasons
+		}
+	}
+}
+
+// createTestPod creates the pod of the ordinal with the labels and marks it
+// running, ready for an hour if ready is set.
+func createTestPod(
+	ctx context.Context,
+	cluster *openldapv1.OpenldapCluster,
+	index int,
+	labels map[string]string,
+	ready bool,
+) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      cluster.PodName(index),
+			Namespace: cluster.Namespace,
+			Labels:    labels,
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: cluster.Name, Image: cluster.GetTemplate().Image}},
+		},
+	}
+	Expect(k8sClient.Create(ctx, pod)).To(Succeed())
+
+	status := corev1.ConditionFalse
+	if ready {
+		status = corev1.ConditionTrue
+	}
+
+	pod.Status = corev1.PodStatus{
+		Phase: corev1.PodRunning,
+		Conditions: []corev1.PodCondition{
+			{
+				Type:               corev1.ContainersReady,
+				Status:             status,
+				LastTransitionTime: metav1.NewTime(time.Now().Add(-time.Hour)),
+			},
+			{
+				Type:               corev1.PodReady,
+				Status:             status,
+				LastTransitionTime: metav1.NewTime(time.Now().Add(-time.Hour)),
+			},
+		},
+	}
+	Expect(k8sClient.Status().Update(ctx, pod)).To(Succeed())
+
+	return pod
+}
+
+// createTestNode creates a node in the zone, cordoned if set.
+func createTestNode(ctx context.Context, zone string, cordoned bool) *corev1.Node {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-" + rand.String(6)},
+		Spec:       corev1.NodeSpec{Unschedulable: cordoned},
+	}
+	if zone != "" {
+		node.Labels = map[string]string{corev1.LabelTopologyZone: zone}
+	}
+	Expect(k8sClient.Create(ctx, node)).To(Succeed())
+
+	return node
+}
+
+// scheduleTestPod binds the pod to the node as the scheduler would.
+func scheduleTestPod(ctx context.Context, pod *corev1.Pod, node *corev1.Node) {
+	Expect(k8sClient.SubResource("binding").Create(ctx, pod, &corev1.Binding{
+		ObjectMeta: metav1.ObjectMeta{Name: pod.Name, Namespace: pod.Namespace},
+		Target:     corev1.ObjectReference{Kind: "Node", Name: node.Name},
+	})).To(Succeed())
+	Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(pod), pod)).To(Succeed())
+}
+
+// storeTestSnapshots stores the snapshots of the pods as the observer would,
+// with the contextCSN of each pod changed at the time in ordinal order.
+func storeTestSnapshots(cluster *openldapv1.OpenldapCluster, changedAt ...time.Time) {
+	podMonitors := map[string]podMonitor{}
+	for i, csn := range changedAt {
+		podMonitors[cluster.PodName(i)] = podMonitor{
+			snapshot: &monitors.MonitorSnapshot{ContextCsn: map[string]time.Time{"000": csn}},
+		}
+	}
+
+	clusterMonitor.store(clusterKey(cluster), podMonitors)
+	DeferCleanup(clusterMonitor.delete, clusterKey(cluster))
+}
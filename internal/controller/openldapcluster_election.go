@@ -3,6 +3,8 @@ package controller
 import (
 	"context"
 	"fmt"
+	"reflect"
+	"sort"
 	"strconv"
 
 	openldapv1 "github.com/qwp0905/openldap-operator/api/v1"
@@ -11,6 +13,7 @@ import (
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
@@ -35,7 +38,7 @@ func (r *OpenldapClusterReconciler) election(
 	}
 
 	if cluster.GetDesiredMaster() == "" {
-		cluster.UpdateDesiredMaster(0)
+		cluster.UpdateDesiredMaster(cluster.InitialMasterIndex())
 
 		if err := r.Status().Update(ctx, cluster); err != nil {
 			logger.Error(err, "Error on Updating Cluster Desired Master....")
@@ -48,10 +51,27 @@ func (r *OpenldapClusterReconciler) election(
 			"First Desired Master to be set %s",
 			cluster.GetDesiredMaster(),
 		)
-		logger.Info("Master Pod Set 0")
+		logger.Info(fmt.Sprintf("Master Pod Set %s", cluster.GetDesiredMaster()))
 		return 2, nil
 	}
 
+	if err := r.updateStatusSummary(ctx, cluster); err != nil {
+		logger.Error(err, "Error on Updating Cluster status summary....")
+		return 0, err
+	}
+
+	if cluster.ReplicaEnabled() {
+		requeue, err := r.migrateStorageClass(ctx, cluster, nil)
+		if err != nil {
+			return 0, err
+		}
+		if requeue {
+			return 2, nil
+		}
+
+		return r.updateReplicaStatus(ctx, cluster)
+	}
+
 	masterPod, err := r.getMasterPod(ctx, cluster)
 	if err != nil {
 		if !errors.IsNotFound(err) {
@@ -65,6 +85,7 @@ func (r *OpenldapClusterReconciler) election(
 		}
 
 		logger.Info("Election Triggered because of Pod Not Found....")
+		observeFailover(cluster, failoverReasonPodNotFound)
 		if err = r.electMaster(ctx, cluster); err != nil {
 			return 0, err
 		}
@@ -79,6 +100,7 @@ func (r *OpenldapClusterReconciler) election(
 		}
 
 		logger.Info("Election Triggered because of Pod Unhealthy....")
+		observeFailover(cluster, failoverReasonUnhealthy)
 		if err = r.electMaster(ctx, cluster); err != nil {
 			return 0, err
 		}
@@ -98,6 +120,7 @@ func (r *OpenldapClusterReconciler) election(
 
 	if utils.IsPodRestart(*masterPod) {
 		logger.Info("Election Triggered because of Pod Restarted....")
+		observeFailover(cluster, failoverReasonRestarted)
 		if err = r.electMaster(ctx, cluster); err != nil {
 			return 0, err
 		}
@@ -119,6 +142,63 @@ func (r *OpenldapClusterReconciler) election(
 				return 0, err
 			}
 
+			if condition := cluster.GetCondition(openldapv1.ConditionElected); condition != nil &&
+				condition.Reason == openldapv1.ReasonReplicaOfSource {
+				r.Recorder.Eventf(
+					cluster,
+					"Normal",
+					"ReplicaPromoted",
+					"Replica cluster promoted, electing %s as master",
+					cluster.GetDesiredMaster(),
+				)
+				promotedAt := metav1.Now()
+				cluster.GetReplicationStatus().PromotedAt = &promotedAt
+				cluster.GetReplicationStatus().LagSeconds = nil
+			}
+
+			acquired, err := r.acquireMasterLease(ctx, cluster)
+			if err != nil {
+				return 0, err
+			}
+			if !acquired {
+				logger.Info("Waiting for lease of old master to expire")
+				return 5, nil
+			}
+
+			if cluster.IsMultiProvider() {
+				cluster.SetConditionElected(
+					true,
+					openldapv1.ReasonProviderActivated,
+					fmt.Sprintf("Provider %s activated without promotion", cluster.GetDesiredMaster()),
+				)
+
+				if err = r.Status().Update(ctx, cluster); err != nil {
+					logger.Error(err, "Error on Updating Status Elected...")
+					return 0, err
+				}
+
+				logger.Info("Provider Activated")
+				return 2, nil
+			}
+
+			// syncrepl of the new master is removed by the observer instead of
+			// the promotion script of the image
+			if cluster.ReplicationManaged() {
+				cluster.SetConditionElected(
+					true,
+					openldapv1.ReasonReplicationSwitched,
+					fmt.Sprintf("Replication switched to %s by the operator", cluster.GetDesiredMaster()),
+				)
+
+				if err = r.Status().Update(ctx, cluster); err != nil {
+					logger.Error(err, "Error on Updating Status Elected...")
+					return 0, err
+				}
+
+				logger.Info("Replication Switched")
+				return 2, nil
+			}
+
 			newJob := jobs.CreateSlaveToMasterJob(cluster)
 
 			if err = r.registerObject(cluster, newJob); err != nil {
@@ -131,7 +211,11 @@ func (r *OpenldapClusterReconciler) election(
 				return 0, err
 			}
 
-			cluster.SetConditionElected(true)
+			cluster.SetConditionElected(
+				true,
+				openldapv1.ReasonPromotionJobCreated,
+				fmt.Sprintf("Promotion job %s created for %s", newJob.Name, cluster.GetDesiredMaster()),
+			)
 
 			if err = r.Status().Update(ctx, cluster); err != nil {
 				logger.Error(err, "Error on Updating Status Elected...")
@@ -161,7 +245,14 @@ func (r *OpenldapClusterReconciler) election(
 			return 0, err
 		}
 
+		zone, err := r.getPodZone(ctx, masterPod)
+		if err != nil {
+			logger.Error(err, "Error on getting zone of master...")
+			return 0, err
+		}
+
 		cluster.UpdateCurrentMaster()
+		cluster.UpdateMasterZone(zone)
 		if err = r.Status().Update(ctx, cluster); err != nil {
 			logger.Error(err, "Error on Updating Status Current master....")
 			return 0, err
@@ -174,13 +265,26 @@ func (r *OpenldapClusterReconciler) election(
 			"Current master updated to %s",
 			cluster.GetCurrentMaster(),
 		)
+		observeElectionCompleted(cluster)
 		logger.Info("Master Pod Updated")
 		return 10, nil
 	}
 
+	requeue, err := r.checkPromotionJob(ctx, cluster)
+	if err != nil {
+		return 0, err
+	}
+	if requeue {
+		return 2, nil
+	}
+
 	if !cluster.IsReady() {
 		cluster.DeleteInitializedCondition()
-		cluster.SetConditionReady(true)
+		cluster.SetConditionReady(
+			true,
+			openldapv1.ReasonClusterReady,
+			fmt.Sprintf("Master %s elected", cluster.GetCurrentMaster()),
+		)
 
 		r.Recorder.Eventf(
 			cluster,
@@ -195,26 +299,301 @@ func (r *OpenldapClusterReconciler) election(
 		}
 	}
 
+	zone, err := r.getPodZone(ctx, masterPod)
+	if err != nil {
+		logger.Error(err, "Error on getting zone of master...")
+		return 0, err
+	}
+	if zone != cluster.GetMasterZone() {
+		cluster.UpdateMasterZone(zone)
+		if err = r.Status().Update(ctx, cluster); err != nil {
+			logger.Error(err, "Error on Updating Status Master zone....")
+			return 0, err
+		}
+	}
+
+	requeue, err = r.switchoverOnScaleDown(ctx, cluster, masterPod)
+	if err != nil {
+		return 0, err
+	}
+	if requeue {
+		return 2, nil
+	}
+
+	requeue, err = r.migrateStorageClass(ctx, cluster, masterPod)
+	if err != nil {
+		return 0, err
+	}
+	if requeue {
+		return 2, nil
+	}
+
+	requeue, err = r.switchoverOnDrain(ctx, cluster, masterPod)
+	if err != nil {
+		return 0, err
+	}
+	if requeue {
+		return 2, nil
+	}
+
+	requeue, err = r.switchBackMaster(ctx, cluster, masterPod)
+	if err != nil {
+		return 0, err
+	}
+	if requeue {
+		return 2, nil
+	}
+
+	if err = r.renewMasterLease(ctx, cluster); err != nil {
+		return 0, err
+	}
+
+	observeCurrentMaster(cluster)
 	logger.Info("Everything ok")
 	return 10, nil
 }
 
+func (r *OpenldapClusterReconciler) checkPromotionJob(
+	ctx context.Context,
+	cluster *openldapv1.OpenldapCluster,
+) (bool, error) {
+	logger := log.FromContext(ctx)
+
+	job, err := r.getJob(ctx, cluster)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			logger.Error(err, "Error on Get job....")
+			return false, err
+		}
+
+		return false, nil
+	}
+
+	if !utils.IsJobFailed(*job) || job.DeletionTimestamp != nil {
+		return false, nil
+	}
+
+	observePromotionJobFailure(cluster)
+	r.Recorder.Eventf(
+		cluster,
+		"Warning",
+		"PromotionJobFailed",
+		"Promotion job %s failed, retrying",
+		job.Name,
+	)
+
+	if err = r.Delete(
+		ctx,
+		job,
+		client.PropagationPolicy(metav1.DeletePropagationBackground),
+	); err != nil {
+		logger.Error(err, "Error on Deleting failed job...")
+		return false, err
+	}
+
+	cluster.SetConditionElected(
+		false,
+		openldapv1.ReasonPromotionJobFailed,
+		fmt.Sprintf("Promotion job %s failed", job.Name),
+	)
+	if err = r.Status().Update(ctx, cluster); err != nil {
+		logger.Error(err, "Error on Updating Status Elected...")
+		return false, err
+	}
+
+	logger.Info("Failed Promotion Job Deleted")
+	return true, nil
+}
+
+// updateReplicaStatus keeps a replica cluster without master, every pod
+// consumes from the external source until the cluster is promoted.
+func (r *OpenldapClusterReconciler) updateReplicaStatus(
+	ctx context.Context,
+	cluster *openldapv1.OpenldapCluster,
+) (int, error) {
+	logger := log.FromContext(ctx)
+	origin := cluster.Status.DeepCopy()
+	source := cluster.ReplicaSourceUrl()
+
+	cluster.DeleteCurrentMaster()
+	cluster.SetConditionElected(
+		false,
+		openldapv1.ReasonReplicaOfSource,
+		fmt.Sprintf("Cluster replicates from %s", source),
+	)
+
+	if cluster.Status.ReadyReplicas > 0 {
+		cluster.DeleteInitializedCondition()
+		cluster.SetConditionReady(
+			true,
+			openldapv1.ReasonReplicaOfSource,
+			fmt.Sprintf("Cluster is a read-only replica of %s", source),
+		)
+	} else {
+		cluster.SetConditionReady(
+			false,
+			openldapv1.ReasonNoHealthyPod,
+			"Waiting for replica pods ready",
+		)
+	}
+
+	if reflect.DeepEqual(origin, &cluster.Status) {
+		return 10, nil
+	}
+
+	if err := r.Status().Update(ctx, cluster); err != nil {
+		logger.Error(err, "Error on Updating Replica status...")
+		return 0, err
+	}
+
+	return 10, nil
+}
+
+func (r *OpenldapClusterReconciler) updateStatusSummary(
+	ctx context.Context,
+	cluster *openldapv1.OpenldapCluster,
+) error {
+	podList := &corev1.PodList{}
+
+	if err := r.List(
+		ctx,
+		podList,
+		client.InNamespace(cluster.Namespace),
+		client.MatchingLabels(cluster.SelectorLabels()),
+	); err != nil {
+		return err
+	}
+
+	readyCount := utils.CountReadyPods(utils.FilterActivePods(podList.Items))
+	observeReadyReplicas(cluster, readyCount)
+
+	replicas := int32(0)
+	statefulset, err := r.getStatefulset(ctx, cluster)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return err
+		}
+	} else {
+		replicas = statefulset.Status.Replicas
+	}
+
+	summaryChanged := cluster.UpdateSummary(replicas, readyCount)
+
+	conditionsChanged, err := r.updateConditions(ctx, cluster)
+	if err != nil {
+		return err
+	}
+
+	if !summaryChanged && !conditionsChanged {
+		return nil
+	}
+
+	return r.Status().Update(ctx, cluster)
+}
+
+// getAlivePodIndex returns the healthy pod to be elected, pods for which skip
+// returns true are never elected. The preferred master is chosen first, then
+// the pod with the highest priority. Among pods with the same priority, a pod
+// in the preferred zone is chosen first, otherwise a pod in a zone different
+// from the failed master, so that a zone outage does not elect a pod in it
+// again.
 func (r *OpenldapClusterReconciler) getAlivePodIndex(
 	ctx context.Context,
 	cluster *openldapv1.OpenldapCluster,
+	skip func(pod *corev1.Pod) (bool, error),
 ) (int, error) {
+	candidates := []electionCandidate{}
+
 	for i := 0; i < cluster.GetReplicas(); i++ {
+		if !cluster.IsElectable(i) {
+			continue
+		}
+
 		pod, err := r.getPod(ctx, cluster, i)
 		if err != nil {
 			continue
 		}
 
-		if utils.IsPodAlive(*pod) && utils.IsPodReady(*pod) {
-			return i, nil
+		if !utils.IsPodAlive(*pod) || !utils.IsPodReady(*pod) {
+			continue
+		}
+
+		if skip != nil {
+			skipped, err := skip(pod)
+			if err != nil {
+				return 0, err
+			}
+			if skipped {
+				continue
+			}
+		}
+
+		zone, err := r.getPodZone(ctx, pod)
+		if err != nil {
+			return 0, err
+		}
+
+		candidates = append(candidates, electionCandidate{
+			index:    i,
+			priority: cluster.ElectionPriority(i),
+			zoneRank: zoneRank(cluster, zone),
+		})
+	}
+
+	if len(candidates) == 0 {
+		return 0, fmt.Errorf("no Pod Alive")
+	}
+
+	for _, candidate := range candidates {
+		if candidate.index == cluster.PreferredMasterIndex() {
+			return candidate.index, nil
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].priority != candidates[j].priority {
+			return candidates[i].priority > candidates[j].priority
+		}
+
+		return candidates[i].zoneRank < candidates[j].zoneRank
+	})
+
+	return candidates[0].index, nil
+}
+
+type electionCandidate struct {
+	index    int
+	priority int32
+	zoneRank int
+}
+
+func zoneRank(cluster *openldapv1.OpenldapCluster, zone string) int {
+	if preferred := cluster.PreferredZone(); preferred != "" && zone == preferred {
+		return 0
+	}
+
+	if failed := cluster.GetMasterZone(); failed == "" || zone != failed {
+		return 1
+	}
+
+	return 2
+}
+
+func (r *OpenldapClusterReconciler) getPodZone(ctx context.Context, pod *corev1.Pod) (string, error) {
+	if pod.Spec.NodeName == "" {
+		return "", nil
+	}
+
+	node := &corev1.Node{}
+	if err := r.Get(ctx, types.NamespacedName{Name: pod.Spec.NodeName}, node); err != nil {
+		if errors.IsNotFound(err) {
+			return "", nil
 		}
+
+		return "", err
 	}
 
-	return 0, fmt.Errorf("no Pod Alive")
+	return node.Labels[corev1.LabelTopologyZone], nil
 }
 
 func (r *OpenldapClusterReconciler) electMaster(
@@ -230,16 +609,28 @@ func (r *OpenldapClusterReconciler) electMaster(
 		cluster.GetCurrentMaster(),
 	)
 
+	if err := r.fenceMaster(ctx, cluster); err != nil {
+		return err
+	}
+
+	cluster.SetConditionElected(
+		false,
+		openldapv1.ReasonMasterUnhealthy,
+		fmt.Sprintf("Election triggered because master %s unhealthy", cluster.GetCurrentMaster()),
+	)
 	cluster.DeleteCurrentMaster()
-	cluster.SetConditionElected(false)
 	if err := r.Status().Update(ctx, cluster); err != nil {
 		logger.Error(err, "Error on Updating Cluster Condition Elected....")
 		return err
 	}
 
-	nextIndex, err := r.getAlivePodIndex(ctx, cluster)
+	nextIndex, err := r.getAlivePodIndex(ctx, cluster, nil)
 	if err != nil {
-		cluster.SetConditionReady(false)
+		cluster.SetConditionReady(
+			false,
+			openldapv1.ReasonNoHealthyPod,
+			"Cannot find healthy pod to elect as master",
+		)
 		if err := r.Status().Update(ctx, cluster); err != nil {
 			logger.Error(err, "Error on Updating Cluster Condition Ready....")
 			return err
@@ -0,0 +1,95 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	openldapv1 "github.com/qwp0905/openldap-operator/api/v1"
+	"github.com/qwp0905/openldap-operator/internal/fixtures"
+)
+
+// createZonedPods creates ready pods of the cluster on nodes of the zones,
+// one pod by zone in ordinal order.
+func createZonedPods(ctx context.Context, cluster *openldapv1.OpenldapCluster, zones ...string) {
+	for i, zone := range zones {
+		pod := createTestPod(ctx, cluster, i, cluster.SelectorLabels(), true)
+		scheduleTestPod(ctx, pod, createTestNode(ctx, zone, false))
+	}
+}
+
+var _ = Describe("Election", func() {
+	ctx := context.Background()
+
+	Context("zones", func() {
+		It("elects a pod out of the zone of the failed master", func() {
+			reconciler, _ := newTestReconciler()
+			cluster := createTestCluster(ctx, 3)
+			createZonedPods(ctx, cluster, "zone-a", "zone-a", "zone-b")
+
+			Expect(reconciler.getAlivePodIndex(ctx, cluster, nil)).To(Equal(0))
+
+			cluster.UpdateMasterZone("zone-a")
+			Expect(reconciler.getAlivePodIndex(ctx, cluster, nil)).To(Equal(2))
+		})
+
+		It("elects a pod in the preferred zone first", func() {
+			reconciler, _ := newTestReconciler()
+			cluster := createTestCluster(ctx, 3)
+			createZonedPods(ctx, cluster, "zone-a", "zone-b", "zone-c")
+
+			cluster.UpdateMasterZone("zone-a")
+			cluster.Spec.Election.PreferredZone = "zone-c"
+			Expect(reconciler.getAlivePodIndex(ctx, cluster, nil)).To(Equal(2))
+		})
+
+		It("reads the zone of the node of the pod", func() {
+			reconciler, _ := newTestReconciler()
+			cluster := createTestCluster(ctx, 1)
+			pod := createTestPod(ctx, cluster, 0, cluster.SelectorLabels(), true)
+
+			Expect(reconciler.getPodZone(ctx, pod)).To(BeEmpty())
+
+			scheduleTestPod(ctx, pod, createTestNode(ctx, "zone-a", false))
+			Expect(reconciler.getPodZone(ctx, pod)).To(Equal("zone-a"))
+		})
+	})
+
+	Context("priorities", func() {
+		It("ranks the preferred zone first and the failed zone last", func() {
+			cluster := fixtures.NewCluster("ldap", 3)
+			cluster.UpdateMasterZone("zone-a")
+			Expect(zoneRank(cluster, "zone-a")).To(Equal(2))
+			Expect(zoneRank(cluster, "zone-b")).To(Equal(1))
+			Expect(zoneRank(cluster, "")).To(Equal(1))
+
+			cluster.Spec.Election.PreferredZone = "zone-b"
+			Expect(zoneRank(cluster, "zone-b")).To(Equal(0))
+		})
+
+		It("elects by priority before zone and never an excluded pod", func() {
+			reconciler, _ := newTestReconciler()
+			cluster := createTestCluster(ctx, 3)
+			createZonedPods(ctx, cluster, "zone-a", "zone-a", "zone-b")
+			cluster.UpdateMasterZone("zone-a")
+			cluster.Spec.Election.Priorities = []openldapv1.OrdinalPriority{{Ordinal: 1, Priority: 10}}
+
+			Expect(reconciler.getAlivePodIndex(ctx, cluster, nil)).To(Equal(1))
+
+			cluster.Spec.Election.ExcludedOrdinals = []int32{1}
+			Expect(reconciler.getAlivePodIndex(ctx, cluster, nil)).To(Equal(2))
+		})
+
+		It("elects the preferred master first", func() {
+			reconciler, _ := newTestReconciler()
+			cluster := createTestCluster(ctx, 3)
+			createZonedPods(ctx, cluster, "zone-a", "zone-a", "zone-b")
+			preferred := int32(1)
+			cluster.Spec.Election.PreferredMaster = &preferred
+
+			Expect(reconciler.getAlivePodIndex(ctx, cluster, nil)).To(Equal(1))
+			Expect(cluster.InitialMasterIndex()).To(Equal(1))
+		})
+	})
+})
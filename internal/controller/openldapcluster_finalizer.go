@@ -0,0 +1,175 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	openldapv1 "github.com/qwp0905/openldap-operator/api/v1"
+	"github.com/qwp0905/openldap-operator/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func (r *OpenldapClusterReconciler) ensureFinalizer(
+	ctx context.Context,
+	cluster *openldapv1.OpenldapCluster,
+) (bool, error) {
+	logger := log.FromContext(ctx)
+
+	if controllerutil.ContainsFinalizer(cluster, openldapv1.ClusterFinalizer) {
+		return false, nil
+	}
+
+	controllerutil.AddFinalizer(cluster, openldapv1.ClusterFinalizer)
+	if err := r.Update(ctx, cluster); err != nil {
+		logger.Error(err, "Error on Adding finalizer...")
+		return false, err
+	}
+
+	logger.Info("Finalizer Added")
+	return true, nil
+}
+
+// finalizeCluster deletes the volume claims left by the statefulset if the
+// retention policy deletes them with the cluster, otherwise labels them as
+// retained, so that a new cluster with the same name does not start on them
+// unless it adopts them explicitly.
+func (r *OpenldapClusterReconciler) finalizeCluster(
+	ctx context.Context,
+	cluster *openldapv1.OpenldapCluster,
+) error {
+	logger := log.FromContext(ctx)
+
+	if !controllerutil.ContainsFinalizer(cluster, openldapv1.ClusterFinalizer) {
+		return nil
+	}
+
+	pvcList, err := r.listDataVolumeClaims(ctx, cluster, cluster.SelectorLabels())
+	if err != nil {
+		logger.Error(err, "Error on listing PersistentVolumeClaims...")
+		return err
+	}
+
+	for i := range pvcList.Items {
+		pvc := &pvcList.Items[i]
+
+		if cluster.VolumesDeletedWithCluster() {
+			if err = r.Delete(ctx, pvc); err != nil && !errors.IsNotFound(err) {
+				logger.Error(err, "Error on deleting PersistentVolumeClaim...")
+				return err
+			}
+
+			logger.Info(fmt.Sprintf("PersistentVolumeClaim %s deleted with cluster", pvc.Name))
+			continue
+		}
+
+		if pvc.Labels[openldapv1.RetainedVolumeLabel] == "true" {
+			continue
+		}
+
+		origin := pvc.DeepCopy()
+		pvc.SetLabels(utils.MergeMap(pvc.GetLabels(), cluster.RetainedVolumeLabels()))
+		pvc.SetAnnotations(utils.MergeMap(
+			pvc.GetAnnotations(),
+			map[string]string{openldapv1.RetainedFromAnnotation: string(cluster.UID)},
+		))
+		if err = r.Patch(ctx, pvc, client.MergeFrom(origin)); err != nil {
+			logger.Error(err, "Error on labeling retained PersistentVolumeClaim...")
+			return err
+		}
+
+		logger.Info(fmt.Sprintf("PersistentVolumeClaim %s retained", pvc.Name))
+	}
+
+	controllerutil.RemoveFinalizer(cluster, openldapv1.ClusterFinalizer)
+	if err = r.Update(ctx, cluster); err != nil {
+		logger.Error(err, "Error on Removing finalizer...")
+		return err
+	}
+
+	logger.Info("Finalizer Removed")
+	return nil
+}
+
+// ensureRetainedVolumes holds the creation of the statefulset while volume
+// claims retained from a deleted cluster with the same name exist, unless
+// they are adopted.
+func (r *OpenldapClusterReconciler) ensureRetainedVolumes(
+	ctx context.Context,
+	cluster *openldapv1.OpenldapCluster,
+) (bool, error) {
+	logger := log.FromContext(ctx)
+
+	pvcList, err := r.listDataVolumeClaims(ctx, cluster, cluster.RetainedVolumeLabels())
+	if err != nil {
+		logger.Error(err, "Error on listing retained PersistentVolumeClaims...")
+		return false, err
+	}
+
+	if len(pvcList.Items) == 0 {
+		return false, nil
+	}
+
+	if !cluster.Spec.Storage.AdoptRetainedVolumes {
+		r.Recorder.Eventf(
+			cluster,
+			"Warning",
+			"RetainedVolumesFound",
+			"%d PersistentVolumeClaims retained from a deleted cluster, "+
+				"set adoptRetainedVolumes to adopt them or delete them",
+			len(pvcList.Items),
+		)
+		logger.Info("Waiting for retained volumes to be adopted or deleted")
+		return true, nil
+	}
+
+	for i := range pvcList.Items {
+		pvc := &pvcList.Items[i]
+		origin := pvc.DeepCopy()
+
+		labels := pvc.GetLabels()
+		delete(labels, openldapv1.RetainedVolumeLabel)
+		pvc.SetLabels(labels)
+
+		annotations := pvc.GetAnnotations()
+		delete(annotations, openldapv1.RetainedFromAnnotation)
+		pvc.SetAnnotations(annotations)
+
+		if err = r.Patch(ctx, pvc, client.MergeFrom(origin)); err != nil {
+			logger.Error(err, "Error on adopting retained PersistentVolumeClaim...")
+			return false, err
+		}
+
+		r.Recorder.Eventf(
+			cluster,
+			"Normal",
+			"RetainedVolumeAdopted",
+			"PersistentVolumeClaim %s adopted",
+			pvc.Name,
+		)
+	}
+
+	return false, nil
+}
+
+func (r *OpenldapClusterReconciler) listDataVolumeClaims(
+	ctx context.Context,
+	cluster *openldapv1.OpenldapCluster,
+	labels map[string]string,
+) (*corev1.PersistentVolumeClaimList, error) {
+	pvcList := &corev1.PersistentVolumeClaimList{}
+
+	if err := r.List(
+		ctx,
+		pvcList,
+		client.InNamespace(cluster.Namespace),
+		client.MatchingLabels(labels),
+	); err != nil {
+		return nil, err
+	}
+
+	return pvcList, nil
+}
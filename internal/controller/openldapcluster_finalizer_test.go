@@ -0,0 +1,85 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	openldapv1 "github.com/qwp0905/openldap-operator/api/v1"
+)
+
+var _ = Describe("Finalizer", func() {
+	ctx := context.Background()
+
+	// createTestVolumes creates the data volume claims of every pod labeled as
+	// created by the statefulset.
+	createTestVolumes := func(cluster *openldapv1.OpenldapCluster) {
+		for i := 0; i < cluster.GetReplicas(); i++ {
+			Expect(k8sClient.Create(ctx, &corev1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      cluster.DataVolumeClaimName(i),
+					Namespace: cluster.Namespace,
+					Labels:    cluster.SelectorLabels(),
+				},
+				Spec: cluster.Spec.Storage.VolumeClaimTemplate,
+			})).To(Succeed())
+		}
+	}
+
+	It("deletes the volume claims with the cluster", func() {
+		reconciler, _ := newTestReconciler()
+		cluster := createTestCluster(ctx, 2)
+		cluster.Spec.Storage.PersistentVolumeClaimRetentionPolicy =
+			&appsv1.StatefulSetPersistentVolumeClaimRetentionPolicy{
+				WhenDeleted: appsv1.DeletePersistentVolumeClaimRetentionPolicyType,
+				WhenScaled:  appsv1.DeletePersistentVolumeClaimRetentionPolicyType,
+			}
+		createTestVolumes(cluster)
+
+		Expect(reconciler.ensureFinalizer(ctx, cluster)).To(BeTrue())
+		Expect(reconciler.ensureFinalizer(ctx, cluster)).To(BeFalse())
+
+		Expect(reconciler.finalizeCluster(ctx, cluster)).To(Succeed())
+		Expect(controllerutil.ContainsFinalizer(getTestCluster(ctx, cluster), openldapv1.ClusterFinalizer)).To(BeFalse())
+
+		for i := 0; i < cluster.GetReplicas(); i++ {
+			pvc, err := reconciler.getDataVolumeClaim(ctx, cluster, i)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pvc.DeletionTimestamp).NotTo(BeNil())
+		}
+	})
+
+	It("holds a new cluster on the retained volume claims until adopted", func() {
+		reconciler, recorder := newTestReconciler()
+		cluster := createTestCluster(ctx, 2)
+		createTestVolumes(cluster)
+
+		Expect(reconciler.ensureFinalizer(ctx, cluster)).To(BeTrue())
+		Expect(reconciler.finalizeCluster(ctx, cluster)).To(Succeed())
+
+		for i := 0; i < cluster.GetReplicas(); i++ {
+			pvc, err := reconciler.getDataVolumeClaim(ctx, cluster, i)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pvc.DeletionTimestamp).To(BeNil())
+			Expect(pvc.Labels).To(HaveKeyWithValue(openldapv1.RetainedVolumeLabel, "true"))
+			Expect(pvc.Annotations).To(HaveKeyWithValue(openldapv1.RetainedFromAnnotation, string(cluster.UID)))
+		}
+
+		Expect(reconciler.ensureRetainedVolumes(ctx, cluster)).To(BeTrue())
+		Expect(recordedReasons(recorder)).To(Equal([]string{"RetainedVolumesFound"}))
+
+		cluster.Spec.Storage.AdoptRetainedVolumes = true
+		Expect(reconciler.ensureRetainedVolumes(ctx, cluster)).To(BeFalse())
+		Expect(recordedReasons(recorder)).To(Equal([]string{"RetainedVolumeAdopted", "RetainedVolumeAdopted"}))
+
+		pvc, err := reconciler.getDataVolumeClaim(ctx, cluster, 0)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pvc.Labels).NotTo(HaveKey(openldapv1.RetainedVolumeLabel))
+		Expect(pvc.Annotations).NotTo(HaveKey(openldapv1.RetainedFromAnnotation))
+	})
+})
@@ -0,0 +1,141 @@
+package controller
+
+import (
+	"context"
+	"reflect"
+
+	openldapv1 "github.com/qwp0905/openldap-operator/api/v1"
+	"github.com/qwp0905/openldap-operator/pkg/autoscalers"
+	"github.com/qwp0905/openldap-operator/pkg/utils"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func (r *OpenldapClusterReconciler) ensureHorizontalPodAutoscaler(
+	ctx context.Context,
+	cluster *openldapv1.OpenldapCluster,
+) (bool, error) {
+	logger := log.FromContext(ctx)
+	existsHpa, err := r.getHorizontalPodAutoscaler(ctx, cluster)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			logger.Error(err, "Error on Get HorizontalPodAutoscaler...")
+			return false, err
+		}
+
+		if !cluster.AutoscalingEnabled() {
+			return false, nil
+		}
+
+		newHpa := autoscalers.CreateHorizontalPodAutoscaler(cluster)
+		if err = r.registerObject(cluster, newHpa); err != nil {
+			logger.Error(err, "Error on Registering HorizontalPodAutoscaler...")
+			return false, err
+		}
+
+		if err = r.Create(ctx, newHpa); err != nil {
+			logger.Error(err, "Error on Creating HorizontalPodAutoscaler...")
+			return false, err
+		}
+
+		r.Recorder.Eventf(
+			cluster,
+			"Normal",
+			"HorizontalPodAutoscalerCreated",
+			"HorizontalPodAutoscaler %s created",
+			newHpa.Name,
+		)
+		logger.Info("HorizontalPodAutoscaler Created")
+		return true, nil
+	}
+
+	if !cluster.AutoscalingEnabled() {
+		if err = r.Delete(ctx, existsHpa); err != nil {
+			logger.Error(err, "Error on Deleting HorizontalPodAutoscaler...")
+			return false, err
+		}
+
+		r.Recorder.Eventf(
+			cluster,
+			"Normal",
+			"HorizontalPodAutoscalerDeleted",
+			"HorizontalPodAutoscaler %s deleted",
+			existsHpa.Name,
+		)
+		logger.Info("HorizontalPodAutoscaler Deleted")
+		return true, nil
+	}
+
+	updatedHpa := autoscalers.CreateHorizontalPodAutoscaler(cluster)
+
+	if r.compareHorizontalPodAutoscaler(existsHpa, updatedHpa) {
+		return false, nil
+	}
+
+	existsHpa.SetLabels(updatedHpa.GetLabels())
+	existsHpa.SetAnnotations(updatedHpa.GetAnnotations())
+	existsHpa.Spec = updatedHpa.Spec
+
+	if err = r.Update(ctx, existsHpa); err != nil {
+		logger.Error(err, "Error on Updating HorizontalPodAutoscaler...")
+		return false, err
+	}
+
+	r.Recorder.Eventf(
+		cluster,
+		"Normal",
+		"HorizontalPodAutoscalerUpdated",
+		"HorizontalPodAutoscaler %s updated",
+		existsHpa.Name,
+	)
+	logger.Info("HorizontalPodAutoscaler Updated")
+	return true, nil
+}
+
+func (r *OpenldapClusterReconciler) getHorizontalPodAutoscaler(
+	ctx context.Context,
+	cluster *openldapv1.OpenldapCluster,
+) (*autoscalingv2.HorizontalPodAutoscaler, error) {
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{}
+
+	if err := r.Get(
+		ctx,
+		types.NamespacedName{Name: cluster.Name, Namespace: cluster.Namespace},
+		hpa,
+	); err != nil {
+		return nil, err
+	}
+
+	return hpa, nil
+}
+
+func (r *OpenldapClusterReconciler) compareHorizontalPodAutoscaler(
+	exists *autoscalingv2.HorizontalPodAutoscaler,
+	new *autoscalingv2.HorizontalPodAutoscaler,
+) bool {
+	if !utils.CompareMap(exists.Labels, new.Labels) {
+		return false
+	}
+
+	if !utils.CompareMap(exists.Annotations, new.Annotations) {
+		return false
+	}
+
+	if !reflect.DeepEqual(exists.Spec.ScaleTargetRef, new.Spec.ScaleTargetRef) {
+		return false
+	}
+
+	if !reflect.DeepEqual(exists.Spec.MinReplicas, new.Spec.MinReplicas) ||
+		exists.Spec.MaxReplicas != new.Spec.MaxReplicas {
+		return false
+	}
+
+	if !equality.Semantic.DeepEqual(exists.Spec.Metrics, new.Spec.Metrics) {
+		return false
+	}
+
+	return equality.Semantic.DeepEqual(exists.Spec.Behavior, new.Spec.Behavior)
+}
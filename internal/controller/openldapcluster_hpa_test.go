@@ -0,0 +1,62 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+
+	"github.com/qwp0905/openldap-operator/pkg/statefulsets"
+)
+
+var _ = Describe("Autoscaling", func() {
+	ctx := context.Background()
+
+	It("creates the autoscaler once enabled and deletes it once disabled", func() {
+		r, recorder := newTestReconciler()
+		cluster := createTestCluster(ctx, 3)
+
+		Expect(r.ensureHorizontalPodAutoscaler(ctx, cluster)).To(BeFalse())
+
+		cpu := int32(70)
+		cluster.Spec.Autoscaling.Enabled = true
+		cluster.Spec.Autoscaling.MaxReplicas = 5
+		cluster.Spec.Autoscaling.TargetCPUUtilizationPercentage = &cpu
+		Expect(r.ensureHorizontalPodAutoscaler(ctx, cluster)).To(BeTrue())
+		Expect(r.ensureHorizontalPodAutoscaler(ctx, cluster)).To(BeFalse())
+
+		hpa, err := r.getHorizontalPodAutoscaler(ctx, cluster)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(hpa.Spec.MaxReplicas).To(Equal(int32(5)))
+
+		cluster.Spec.Autoscaling.Enabled = false
+		Expect(r.ensureHorizontalPodAutoscaler(ctx, cluster)).To(BeTrue())
+		Expect(recordedReasons(recorder)).To(Equal([]string{
+			"HorizontalPodAutoscalerCreated",
+			"HorizontalPodAutoscalerDeleted",
+		}))
+
+		_, err = r.getHorizontalPodAutoscaler(ctx, cluster)
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("reports the replicas of the statefulset", func() {
+		r, _ := newTestReconciler()
+		cluster := createTestCluster(ctx, 3)
+
+		Expect(r.updateStatusSummary(ctx, cluster)).To(Succeed())
+		Expect(cluster.Status.Replicas).To(Equal(int32(0)))
+
+		statefulset := statefulsets.CreateStatefulset(cluster.DeepCopy())
+		Expect(r.registerObject(cluster, statefulset)).To(Succeed())
+		Expect(k8sClient.Create(ctx, statefulset)).To(Succeed())
+		statefulset.Status = appsv1.StatefulSetStatus{Replicas: 2}
+		Expect(k8sClient.Status().Update(ctx, statefulset)).To(Succeed())
+
+		Expect(r.updateStatusSummary(ctx, cluster)).To(Succeed())
+		Expect(cluster.Status.Replicas).To(Equal(int32(2)))
+		Expect(getTestCluster(ctx, cluster).Status.Replicas).To(Equal(int32(2)))
+	})
+})
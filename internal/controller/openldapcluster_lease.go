@@ -0,0 +1,215 @@
+package controller
+
+import (
+	"context"
+
+	openldapv1 "github.com/qwp0905/openldap-operator/api/v1"
+	"github.com/qwp0905/openldap-operator/pkg/leases"
+	"github.com/qwp0905/openldap-operator/pkg/utils"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// renewMasterLease renews the lease held by the current master, the fencer
+// of the master makes the database read-only once it is not renewed.
+func (r *OpenldapClusterReconciler) renewMasterLease(
+	ctx context.Context,
+	cluster *openldapv1.OpenldapCluster,
+) error {
+	logger := log.FromContext(ctx)
+
+	if !cluster.FencingEnabled() {
+		return nil
+	}
+
+	lease, err := r.getLease(ctx, cluster)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			logger.Error(err, "Error on Get Lease...")
+			return err
+		}
+
+		return r.createLease(ctx, cluster, cluster.GetCurrentMaster())
+	}
+
+	updateLeaseHolder(cluster, lease, cluster.GetCurrentMaster())
+	if err = r.Update(ctx, lease); err != nil {
+		logger.Error(err, "Error on Renewing Lease...")
+		return err
+	}
+
+	return nil
+}
+
+// acquireMasterLease hands the lease over to the desired master. It returns
+// false while the lease is still held by the old master.
+func (r *OpenldapClusterReconciler) acquireMasterLease(
+	ctx context.Context,
+	cluster *openldapv1.OpenldapCluster,
+) (bool, error) {
+	logger := log.FromContext(ctx)
+
+	if !cluster.FencingEnabled() {
+		return true, nil
+	}
+
+	lease, err := r.getLease(ctx, cluster)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			logger.Error(err, "Error on Get Lease...")
+			return false, err
+		}
+
+		return true, r.createLease(ctx, cluster, cluster.GetDesiredMaster())
+	}
+
+	if leases.GetHolder(lease) != cluster.GetDesiredMaster() && !leases.IsLeaseExpired(lease) {
+		return false, nil
+	}
+
+	updateLeaseHolder(cluster, lease, cluster.GetDesiredMaster())
+	if err = r.Update(ctx, lease); err != nil {
+		logger.Error(err, "Error on Acquiring Lease...")
+		return false, err
+	}
+
+	r.Recorder.Eventf(
+		cluster,
+		"Normal",
+		"LeaseAcquired",
+		"Lease %s acquired by %s",
+		lease.Name,
+		cluster.GetDesiredMaster(),
+	)
+	return true, nil
+}
+
+// fenceMaster releases the lease of the current master and removes its master
+// labels, so that the write service does not route to it anymore. The lease
+// keeps the release time, a new master is promoted after the lease duration.
+func (r *OpenldapClusterReconciler) fenceMaster(
+	ctx context.Context,
+	cluster *openldapv1.OpenldapCluster,
+) error {
+	logger := log.FromContext(ctx)
+
+	if !cluster.FencingEnabled() || cluster.GetCurrentMaster() == "" {
+		return nil
+	}
+
+	pod := &corev1.Pod{}
+	if err := r.Get(
+		ctx,
+		types.NamespacedName{Name: cluster.GetCurrentMaster(), Namespace: cluster.Namespace},
+		pod,
+	); err != nil {
+		if !errors.IsNotFound(err) {
+			logger.Error(err, "Error on getting old master pod...")
+			return err
+		}
+	} else if pod.DeletionTimestamp == nil {
+		origin := pod.DeepCopy()
+		pod.SetLabels(utils.MergeMap(pod.GetLabels(), cluster.SlaveSelectorLabels()))
+		if err = r.Patch(ctx, pod, client.MergeFrom(origin)); err != nil {
+			logger.Error(err, "Error on removing master labels from old master...")
+			return err
+		}
+	}
+
+	lease, err := r.getLease(ctx, cluster)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			logger.Error(err, "Error on Get Lease...")
+			return err
+		}
+
+		return nil
+	}
+
+	updateLeaseHolder(cluster, lease, "")
+	if err = r.Update(ctx, lease); err != nil {
+		logger.Error(err, "Error on Releasing Lease...")
+		return err
+	}
+
+	r.Recorder.Eventf(
+		cluster,
+		"Warning",
+		"MasterFenced",
+		"Master %s fenced, lease %s released",
+		cluster.GetCurrentMaster(),
+		lease.Name,
+	)
+	return nil
+}
+
+func (r *OpenldapClusterReconciler) createLease(
+	ctx context.Context,
+	cluster *openldapv1.OpenldapCluster,
+	holder string,
+) error {
+	logger := log.FromContext(ctx)
+
+	newLease := leases.CreateMasterLease(cluster, holder)
+	if err := r.registerObject(cluster, newLease); err != nil {
+		logger.Error(err, "Error on Registering Lease...")
+		return err
+	}
+
+	if err := r.Create(ctx, newLease); err != nil {
+		logger.Error(err, "Error on Creating Lease...")
+		return err
+	}
+
+	r.Recorder.Eventf(
+		cluster,
+		"Normal",
+		"LeaseCreated",
+		"Lease %s created",
+		newLease.Name,
+	)
+	logger.Info("Lease Created")
+	return nil
+}
+
+func (r *OpenldapClusterReconciler) getLease(
+	ctx context.Context,
+	cluster *openldapv1.OpenldapCluster,
+) (*coordinationv1.Lease, error) {
+	lease := &coordinationv1.Lease{}
+
+	if err := r.Get(
+		ctx,
+		types.NamespacedName{Name: cluster.LeaseName(), Namespace: cluster.Namespace},
+		lease,
+	); err != nil {
+		return nil, err
+	}
+
+	return lease, nil
+}
+
+func updateLeaseHolder(cluster *openldapv1.OpenldapCluster, lease *coordinationv1.Lease, holder string) {
+	now := metav1.NowMicro()
+	duration := cluster.GetFencing().LeaseDurationSeconds
+
+	if leases.GetHolder(lease) != holder {
+		transitions := int32(0)
+		if lease.Spec.LeaseTransitions != nil {
+			transitions = *lease.Spec.LeaseTransitions
+		}
+		transitions++
+
+		lease.Spec.HolderIdentity = &holder
+		lease.Spec.AcquireTime = &now
+		lease.Spec.LeaseTransitions = &transitions
+	}
+
+	lease.Spec.RenewTime = &now
+	lease.Spec.LeaseDurationSeconds = &duration
+}
@@ -0,0 +1,65 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	openldapv1 "github.com/qwp0905/openldap-operator/api/v1"
+	"github.com/qwp0905/openldap-operator/pkg/leases"
+)
+
+var _ = Describe("Master lease", func() {
+	ctx := context.Background()
+
+	// createFencedCluster returns a cluster with fencing enabled and ldap-0
+	// holding the lease.
+	createFencedCluster := func(reconciler *OpenldapClusterReconciler) *openldapv1.OpenldapCluster {
+		cluster := createTestCluster(ctx, 3)
+		cluster.UpdateDesiredMaster(0)
+		cluster.UpdateCurrentMaster()
+		Expect(k8sClient.Status().Update(ctx, cluster)).To(Succeed())
+
+		cluster.Spec.Election.Fencing.Enabled = true
+		Expect(reconciler.renewMasterLease(ctx, cluster)).To(Succeed())
+
+		return cluster
+	}
+
+	It("is renewed for the current master", func() {
+		reconciler, recorder := newTestReconciler()
+		cluster := createFencedCluster(reconciler)
+		Expect(recordedReasons(recorder)).To(ContainElement("LeaseCreated"))
+
+		lease, err := reconciler.getLease(ctx, cluster)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(leases.GetHolder(lease)).To(Equal(cluster.PodName(0)))
+	})
+
+	It("is handed over only after the lease of the old master expired", func() {
+		reconciler, recorder := newTestReconciler()
+		cluster := createFencedCluster(reconciler)
+		createTestPod(ctx, cluster, 0, cluster.MasterSelectorLabels(), true)
+
+		Expect(reconciler.fenceMaster(ctx, cluster)).To(Succeed())
+		Expect(recordedReasons(recorder)).To(ContainElement("MasterFenced"))
+
+		cluster.UpdateDesiredMaster(1)
+		Expect(reconciler.acquireMasterLease(ctx, cluster)).To(BeFalse())
+
+		lease, err := reconciler.getLease(ctx, cluster)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(leases.GetHolder(lease)).To(BeEmpty())
+		expired := metav1.NewMicroTime(time.Now().Add(-time.Minute))
+		lease.Spec.RenewTime = &expired
+		Expect(k8sClient.Update(ctx, lease)).To(Succeed())
+
+		Expect(reconciler.acquireMasterLease(ctx, cluster)).To(BeTrue())
+		lease, err = reconciler.getLease(ctx, cluster)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(leases.GetHolder(lease)).To(Equal(cluster.PodName(1)))
+	})
+})
@@ -0,0 +1,145 @@
+package controller
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	openldapv1 "github.com/qwp0905/openldap-operator/api/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	failoverReasonPodNotFound     = "pod_not_found"
+	failoverReasonUnhealthy       = "unhealthy"
+	failoverReasonRestarted       = "restarted"
+	failoverReasonNodeDrain       = "node_drain"
+	failoverReasonPreferredMaster = "preferred_master"
+	failoverReasonNotElectable    = "not_electable"
+	failoverReasonScaleDown       = "scale_down"
+	failoverReasonStorage         = "storage_migration"
+)
+
+var (
+	failoversTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "openldap_operator_failovers_total",
+			Help: "Number of master failovers triggered, by reason",
+		},
+		[]string{"namespace", "cluster", "reason"},
+	)
+
+	electionDurationSeconds = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "openldap_operator_election_duration_seconds",
+			Help:    "Time from master loss to election completed",
+			Buckets: []float64{5, 10, 20, 30, 60, 120, 300, 600},
+		},
+		[]string{"namespace", "cluster"},
+	)
+
+	currentMaster = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "openldap_operator_current_master",
+			Help: "Current master pod of the cluster, 1 for the master pod",
+		},
+		[]string{"namespace", "cluster", "pod"},
+	)
+
+	readyReplicas = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "openldap_operator_ready_replicas",
+			Help: "Number of ready pods in the cluster",
+		},
+		[]string{"namespace", "cluster"},
+	)
+
+	promotionJobFailuresTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "openldap_operator_promotion_job_failures_total",
+			Help: "Number of failed slave to master promotion jobs",
+		},
+		[]string{"namespace", "cluster"},
+	)
+
+	reconcileErrorsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "openldap_operator_reconcile_errors_total",
+			Help: "Number of reconcile errors, by reconcile step",
+		},
+		[]string{"namespace", "cluster", "step"},
+	)
+
+	// failoverStartedAt keeps the time master was lost for each cluster
+	// until the election is completed.
+	failoverStartedAt sync.Map
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		failoversTotal,
+		electionDurationSeconds,
+		currentMaster,
+		readyReplicas,
+		promotionJobFailuresTotal,
+		reconcileErrorsTotal,
+	)
+}
+
+func clusterKey(cluster *openldapv1.OpenldapCluster) types.NamespacedName {
+	return types.NamespacedName{Name: cluster.Name, Namespace: cluster.Namespace}
+}
+
+func observeFailover(cluster *openldapv1.OpenldapCluster, reason string) {
+	failoversTotal.WithLabelValues(cluster.Namespace, cluster.Name, reason).Inc()
+	failoverStartedAt.LoadOrStore(clusterKey(cluster), time.Now())
+}
+
+func observeElectionCompleted(cluster *openldapv1.OpenldapCluster) {
+	if startedAt, ok := failoverStartedAt.LoadAndDelete(clusterKey(cluster)); ok {
+		electionDurationSeconds.
+			WithLabelValues(cluster.Namespace, cluster.Name).
+			Observe(time.Since(startedAt.(time.Time)).Seconds())
+	}
+
+	observeCurrentMaster(cluster)
+}
+
+func observeCurrentMaster(cluster *openldapv1.OpenldapCluster) {
+	currentMaster.DeletePartialMatch(prometheus.Labels{
+		"namespace": cluster.Namespace,
+		"cluster":   cluster.Name,
+	})
+	currentMaster.
+		WithLabelValues(cluster.Namespace, cluster.Name, cluster.GetCurrentMaster()).
+		Set(1)
+}
+
+func observeReadyReplicas(cluster *openldapv1.OpenldapCluster, count int) {
+	readyReplicas.WithLabelValues(cluster.Namespace, cluster.Name).Set(float64(count))
+}
+
+func observePromotionJobFailure(cluster *openldapv1.OpenldapCluster) {
+	promotionJobFailuresTotal.WithLabelValues(cluster.Namespace, cluster.Name).Inc()
+}
+
+func observeReconcileError(cluster *openldapv1.OpenldapCluster, step string) {
+	reconcileErrorsTotal.WithLabelValues(cluster.Namespace, cluster.Name, step).Inc()
+}
+
+func deleteClusterMetrics(key types.NamespacedName) {
+	labels := prometheus.Labels{"namespace": key.Namespace, "cluster": key.Name}
+
+	failoversTotal.DeletePartialMatch(labels)
+	electionDurationSeconds.DeletePartialMatch(labels)
+	currentMaster.DeletePartialMatch(labels)
+	readyReplicas.DeletePartialMatch(labels)
+	promotionJobFailuresTotal.DeletePartialMatch(labels)
+	reconcileErrorsTotal.DeletePartialMatch(labels)
+	failoverStartedAt.Delete(key)
+	clusterMonitor.delete(key)
+	configReplicationStatuses.Delete(key)
+	scaleDownHeld.Delete(key)
+	replicationLags.Delete(key)
+}
@@ -0,0 +1,64 @@
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/qwp0905/openldap-operator/internal/fixtures"
+)
+
+// electionSamples returns the number of elections observed for the cluster.
+func electionSamples(namespace, name string) uint64 {
+	metric := &dto.Metric{}
+	observer := electionDurationSeconds.WithLabelValues(namespace, name)
+	Expect(observer.(prometheus.Histogram).Write(metric)).To(Succeed())
+
+	return metric.GetHistogram().GetSampleCount()
+}
+
+var _ = Describe("Operator metrics", func() {
+	It("times the election from the first failover", func() {
+		cluster := fixtures.NewCluster("ldap-metrics", 3)
+		defer deleteClusterMetrics(clusterKey(cluster))
+
+		observeFailover(cluster, failoverReasonUnhealthy)
+		observeFailover(cluster, failoverReasonPodNotFound)
+		Expect(testutil.ToFloat64(
+			failoversTotal.WithLabelValues(cluster.Namespace, cluster.Name, failoverReasonUnhealthy),
+		)).To(Equal(1.0))
+
+		cluster.Status.CurrentMaster = cluster.PodName(1)
+		observeElectionCompleted(cluster)
+		Expect(electionSamples(cluster.Namespace, cluster.Name)).To(Equal(uint64(1)))
+
+		observeElectionCompleted(cluster)
+		Expect(electionSamples(cluster.Namespace, cluster.Name)).To(Equal(uint64(1)))
+	})
+
+	It("keeps a single current master per cluster", func() {
+		cluster := fixtures.NewCluster("ldap-metrics", 3)
+		defer deleteClusterMetrics(clusterKey(cluster))
+
+		cluster.Status.CurrentMaster = cluster.PodName(0)
+		observeCurrentMaster(cluster)
+		cluster.Status.CurrentMaster = cluster.PodName(2)
+		observeCurrentMaster(cluster)
+
+		Expect(currentMaster.DeleteLabelValues(cluster.Namespace, cluster.Name, cluster.PodName(0))).To(BeFalse())
+		Expect(currentMaster.DeleteLabelValues(cluster.Namespace, cluster.Name, cluster.PodName(2))).To(BeTrue())
+	})
+
+	It("drops the series of a deleted cluster", func() {
+		cluster := fixtures.NewCluster("ldap-metrics", 3)
+
+		observeReconcileError(cluster, "statefulset")
+		observeReadyReplicas(cluster, 2)
+		deleteClusterMetrics(clusterKey(cluster))
+
+		Expect(reconcileErrorsTotal.DeleteLabelValues(cluster.Namespace, cluster.Name, "statefulset")).To(BeFalse())
+		Expect(readyReplicas.DeleteLabelValues(cluster.Namespace, cluster.Name)).To(BeFalse())
+	})
+})
@@ -2,6 +2,7 @@ package controller
 
 import (
 	"context"
+	"reflect"
 
 	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
 	openldapv1 "github.com/qwp0905/openldap-operator/api/v1"
@@ -24,7 +25,7 @@ func (r *OpenldapClusterReconciler) ensureServiceMonitor(
 			return false, err
 		}
 
-		if !cluster.MonitorEnabled() {
+		if !cluster.ExporterEnabled() {
 			return false, nil
 		}
 
@@ -50,7 +51,7 @@ func (r *OpenldapClusterReconciler) ensureServiceMonitor(
 		return true, nil
 	}
 
-	if !cluster.MonitorEnabled() {
+	if !cluster.ExporterEnabled() {
 		if err = r.Delete(ctx, existsServiceMonitor); err != nil {
 			logger.Error(err, "Error on Deleting ServiceMonitor...")
 			return false, err
@@ -127,5 +128,121 @@ func (r *OpenldapClusterReconciler) compareServiceMonitor(
 
 	return exEp.Path == neEp.Path &&
 		exEp.Port == neEp.Port &&
-		exEp.Interval == neEp.Interval
+		exEp.Interval == neEp.Interval &&
+		exEp.ScrapeTimeout == neEp.ScrapeTimeout &&
+		reflect.DeepEqual(exEp.RelabelConfigs, neEp.RelabelConfigs) &&
+		reflect.DeepEqual(exEp.MetricRelabelConfigs, neEp.MetricRelabelConfigs)
+}
+
+func (r *OpenldapClusterReconciler) ensurePrometheusRule(
+	ctx context.Context,
+	cluster *openldapv1.OpenldapCluster,
+) (bool, error) {
+	logger := log.FromContext(ctx)
+	existsPrometheusRule, err := r.getPrometheusRule(ctx, cluster)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			logger.Error(err, "Error on Get PrometheusRule...")
+			return false, err
+		}
+
+		if !cluster.MonitorRulesEnabled() {
+			return false, nil
+		}
+
+		newPrometheusRule := monitors.CreatePrometheusRule(cluster)
+		if err = r.registerObject(cluster, newPrometheusRule); err != nil {
+			logger.Error(err, "Error on Registering PrometheusRule...")
+			return false, err
+		}
+
+		if err = r.Create(ctx, newPrometheusRule); err != nil {
+			logger.Error(err, "Error on Creating PrometheusRule...")
+			return false, err
+		}
+
+		r.Recorder.Eventf(
+			cluster,
+			"Normal",
+			"PrometheusRuleCreated",
+			"PrometheusRule %s created",
+			newPrometheusRule.Name,
+		)
+		logger.Info("PrometheusRule Created")
+		return true, nil
+	}
+
+	if !cluster.MonitorRulesEnabled() {
+		if err = r.Delete(ctx, existsPrometheusRule); err != nil {
+			logger.Error(err, "Error on Deleting PrometheusRule...")
+			return false, err
+		}
+
+		r.Recorder.Eventf(
+			cluster,
+			"Normal",
+			"PrometheusRuleDeleted",
+			"PrometheusRule %s deleted",
+			existsPrometheusRule.Name,
+		)
+		logger.Info("PrometheusRule Deleted")
+		return true, nil
+	}
+
+	updatedPrometheusRule := monitors.CreatePrometheusRule(cluster)
+
+	if r.comparePrometheusRule(existsPrometheusRule, updatedPrometheusRule) {
+		return false, nil
+	}
+
+	existsPrometheusRule.SetLabels(updatedPrometheusRule.GetLabels())
+	existsPrometheusRule.SetAnnotations(updatedPrometheusRule.GetAnnotations())
+	existsPrometheusRule.Spec = updatedPrometheusRule.Spec
+
+	if err = r.Update(ctx, existsPrometheusRule); err != nil {
+		logger.Error(err, "Error on Updating PrometheusRule...")
+		return false, err
+	}
+
+	r.Recorder.Eventf(
+		cluster,
+		"Normal",
+		"PrometheusRuleUpdated",
+		"PrometheusRule %s updated",
+		existsPrometheusRule.Name,
+	)
+	logger.Info("PrometheusRule Updated")
+	return true, nil
+}
+
+func (r *OpenldapClusterReconciler) getPrometheusRule(
+	ctx context.Context,
+	cluster *openldapv1.OpenldapCluster,
+) (*monitoringv1.PrometheusRule, error) {
+	rule := &monitoringv1.PrometheusRule{}
+
+	if err := r.Get(
+		ctx,
+		types.NamespacedName{Name: cluster.Name, Namespace: cluster.Namespace},
+		rule,
+	); err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+func (r *OpenldapClusterReconciler) comparePrometheusRule(
+	exists *monitoringv1.PrometheusRule,
+	new *monitoringv1.PrometheusRule,
+) bool {
+	if !utils.CompareMap(exists.Labels, new.Labels) {
+		return false
+	}
+
+	if !utils.CompareMap(exists.Annotations, new.Annotations) {
+		return false
+	}
+
+	return reflect.DeepEqual(exists.Spec, new.Spec)
 }
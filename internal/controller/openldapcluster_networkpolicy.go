@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"context"
+	"reflect"
+
+	openldapv1 "github.com/qwp0905/openldap-operator/api/v1"
+	"github.com/qwp0905/openldap-operator/pkg/networkpolicies"
+	"github.com/qwp0905/openldap-operator/pkg/utils"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func (r *OpenldapClusterReconciler) ensureNetworkPolicy(
+	ctx context.Context,
+	cluster *openldapv1.OpenldapCluster,
+) (bool, error) {
+	logger := log.FromContext(ctx)
+	existsNetworkPolicy, err := r.getNetworkPolicy(ctx, cluster)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			logger.Error(err, "Error on Get NetworkPolicy...")
+			return false, err
+		}
+
+		if !cluster.NetworkPolicyEnabled() {
+			return false, nil
+		}
+
+		newNetworkPolicy := networkpolicies.CreateNetworkPolicy(cluster, r.OperatorNamespace)
+		if err = r.registerObject(cluster, newNetworkPolicy); err != nil {
+			logger.Error(err, "Error on Registering NetworkPolicy...")
+			return false, err
+		}
+
+		if err = r.Create(ctx, newNetworkPolicy); err != nil {
+			logger.Error(err, "Error on Creating NetworkPolicy...")
+			return false, err
+		}
+
+		r.Recorder.Eventf(
+			cluster,
+			"Normal",
+			"NetworkPolicyCreated",
+			"NetworkPolicy %s created",
+			newNetworkPolicy.Name,
+		)
+		logger.Info("NetworkPolicy Created")
+		return true, nil
+	}
+
+	if !cluster.NetworkPolicyEnabled() {
+		if err = r.Delete(ctx, existsNetworkPolicy); err != nil {
+			logger.Error(err, "Error on Deleting NetworkPolicy...")
+			return false, err
+		}
+
+		r.Recorder.Eventf(
+			cluster,
+			"Normal",
+			"NetworkPolicyDeleted",
+			"NetworkPolicy %s deleted",
+			existsNetworkPolicy.Name,
+		)
+		logger.Info("NetworkPolicy Deleted")
+		return true, nil
+	}
+
+	updatedNetworkPolicy := networkpolicies.CreateNetworkPolicy(cluster, r.OperatorNamespace)
+
+	if r.compareNetworkPolicy(existsNetworkPolicy, updatedNetworkPolicy) {
+		return false, nil
+	}
+
+	existsNetworkPolicy.SetLabels(updatedNetworkPolicy.GetLabels())
+	existsNetworkPolicy.SetAnnotations(updatedNetworkPolicy.GetAnnotations())
+	existsNetworkPolicy.Spec = updatedNetworkPolicy.Spec
+
+	if err = r.Update(ctx, existsNetworkPolicy); err != nil {
+		logger.Error(err, "Error on Updating NetworkPolicy...")
+		return false, err
+	}
+
+	r.Recorder.Eventf(
+		cluster,
+		"Normal",
+		"NetworkPolicyUpdated",
+		"NetworkPolicy %s updated",
+		existsNetworkPolicy.Name,
+	)
+	logger.Info("NetworkPolicy Updated")
+	return true, nil
+}
+
+func (r *OpenldapClusterReconciler) getNetworkPolicy(
+	ctx context.Context,
+	cluster *openldapv1.OpenldapCluster,
+) (*networkingv1.NetworkPolicy, error) {
+	networkPolicy := &networkingv1.NetworkPolicy{}
+
+	if err := r.Get(
+		ctx,
+		types.NamespacedName{Name: cluster.Name, Namespace: cluster.Namespace},
+		networkPolicy,
+	); err != nil {
+		return nil, err
+	}
+
+	return networkPolicy, nil
+}
+
+func (r *OpenldapClusterReconciler) compareNetworkPolicy(
+	exists *networkingv1.NetworkPolicy,
+	new *networkingv1.NetworkPolicy,
+) bool {
+	if !utils.CompareMap(exists.Labels, new.Labels) {
+		return false
+	}
+
+	if !utils.CompareMap(exists.Annotations, new.Annotations) {
+		return false
+	}
+
+	return reflect.DeepEqual(exists.Spec, new.Spec)
+}
@@ -0,0 +1,38 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ = Describe("NetworkPolicy", func() {
+	ctx := context.Background()
+
+	It("is created while enabled and deleted once disabled", func() {
+		r, recorder := newTestReconciler()
+		r.OperatorNamespace = "openldap-operator"
+		cluster := createTestCluster(ctx, 3)
+
+		Expect(r.ensureNetworkPolicy(ctx, cluster)).To(BeFalse())
+		Expect(recordedReasons(recorder)).To(BeEmpty())
+
+		cluster.Spec.NetworkPolicy.Enabled = true
+		cluster.SetDefault()
+		Expect(r.ensureNetworkPolicy(ctx, cluster)).To(BeTrue())
+		Expect(recordedReasons(recorder)).To(ContainElement("NetworkPolicyCreated"))
+
+		policy := &networkingv1.NetworkPolicy{}
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(cluster), policy)).To(Succeed())
+		Expect(r.ensureNetworkPolicy(ctx, cluster)).To(BeFalse())
+
+		cluster.Spec.NetworkPolicy.Enabled = false
+		Expect(r.ensureNetworkPolicy(ctx, cluster)).To(BeTrue())
+		Expect(recordedReasons(recorder)).To(ContainElement("NetworkPolicyDeleted"))
+		Expect(errors.IsNotFound(k8sClient.Get(ctx, client.ObjectKeyFromObject(cluster), policy))).To(BeTrue())
+	})
+})
@@ -0,0 +1,69 @@
+package controller
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	openldapv1 "github.com/qwp0905/openldap-operator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// observeInterval is how often the pods of every cluster are queried over
+// ldap. Queries run apart from the reconciliation, so that an unreachable pod
+// never delays the election of any cluster.
+const observeInterval = 15 * time.Second
+
+// observe runs until the manager stops, it is added to the manager as a
+// runnable and only runs on the leader.
+func (r *OpenldapClusterReconciler) observe(ctx context.Context) error {
+	ticker := time.NewTicker(observeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			r.observeClusters(ctx)
+		}
+	}
+}
+
+// observeClusters queries every cluster concurrently and waits for all of
+// them, so that rounds never overlap.
+func (r *OpenldapClusterReconciler) observeClusters(ctx context.Context) {
+	logger := log.FromContext(ctx)
+
+	clusterList := &openldapv1.OpenldapClusterList{}
+	if err := r.List(ctx, clusterList); err != nil {
+		logger.Error(err, "Error on listing clusters to observe...")
+		return
+	}
+
+	wg := sync.WaitGroup{}
+	for i := range clusterList.Items {
+		cluster := clusterList.Items[i].DeepCopy()
+		if !cluster.DeletionTimestamp.IsZero() {
+			continue
+		}
+		cluster.SetDefault()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.observeCluster(
+				log.IntoContext(ctx, logger.WithValues("namespace", cluster.Namespace, "name", cluster.Name)),
+				cluster,
+			)
+		}()
+	}
+	wg.Wait()
+}
+
+func (r *OpenldapClusterReconciler) observeCluster(ctx context.Context, cluster *openldapv1.OpenldapCluster) {
+	r.scrapeMonitor(ctx, cluster)
+	r.configureReplication(ctx, cluster)
+	r.observeReplicationLag(ctx, cluster)
+	r.observeConfigReplication(ctx, cluster)
+}
@@ -0,0 +1,135 @@
+package controller
+
+import (
+	"context"
+	"reflect"
+
+	openldapv1 "github.com/qwp0905/openldap-operator/api/v1"
+	"github.com/qwp0905/openldap-operator/pkg/pdbs"
+	"github.com/qwp0905/openldap-operator/pkg/utils"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func (r *OpenldapClusterReconciler) ensurePodDisruptionBudget(
+	ctx context.Context,
+	cluster *openldapv1.OpenldapCluster,
+) (bool, error) {
+	logger := log.FromContext(ctx)
+	existsPdb, err := r.getPodDisruptionBudget(ctx, cluster)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			logger.Error(err, "Error on Get PodDisruptionBudget...")
+			return false, err
+		}
+
+		if !cluster.PodDisruptionBudgetEnabled() {
+			return false, nil
+		}
+
+		newPdb := pdbs.CreatePodDisruptionBudget(cluster)
+		if err = r.registerObject(cluster, newPdb); err != nil {
+			logger.Error(err, "Error on Registering PodDisruptionBudget...")
+			return false, err
+		}
+
+		if err = r.Create(ctx, newPdb); err != nil {
+			logger.Error(err, "Error on Creating PodDisruptionBudget...")
+			return false, err
+		}
+
+		r.Recorder.Eventf(
+			cluster,
+			"Normal",
+			"PodDisruptionBudgetCreated",
+			"PodDisruptionBudget %s created",
+			newPdb.Name,
+		)
+		logger.Info("PodDisruptionBudget Created")
+		return true, nil
+	}
+
+	if !cluster.PodDisruptionBudgetEnabled() {
+		if err = r.Delete(ctx, existsPdb); err != nil {
+			logger.Error(err, "Error on Deleting PodDisruptionBudget...")
+			return false, err
+		}
+
+		r.Recorder.Eventf(
+			cluster,
+			"Normal",
+			"PodDisruptionBudgetDeleted",
+			"PodDisruptionBudget %s deleted",
+			existsPdb.Name,
+		)
+		logger.Info("PodDisruptionBudget Deleted")
+		return true, nil
+	}
+
+	updatedPdb := pdbs.CreatePodDisruptionBudget(cluster)
+
+	if r.comparePodDisruptionBudget(existsPdb, updatedPdb) {
+		return false, nil
+	}
+
+	existsPdb.SetLabels(updatedPdb.GetLabels())
+	existsPdb.SetAnnotations(updatedPdb.GetAnnotations())
+	existsPdb.Spec = updatedPdb.Spec
+
+	if err = r.Update(ctx, existsPdb); err != nil {
+		logger.Error(err, "Error on Updating PodDisruptionBudget...")
+		return false, err
+	}
+
+	r.Recorder.Eventf(
+		cluster,
+		"Normal",
+		"PodDisruptionBudgetUpdated",
+		"PodDisruptionBudget %s updated",
+		existsPdb.Name,
+	)
+	logger.Info("PodDisruptionBudget Updated")
+	return true, nil
+}
+
+func (r *OpenldapClusterReconciler) getPodDisruptionBudget(
+	ctx context.Context,
+	cluster *openldapv1.OpenldapCluster,
+) (*policyv1.PodDisruptionBudget, error) {
+	pdb := &policyv1.PodDisruptionBudget{}
+
+	if err := r.Get(
+		ctx,
+		types.NamespacedName{Name: cluster.Name, Namespace: cluster.Namespace},
+		pdb,
+	); err != nil {
+		return nil, err
+	}
+
+	return pdb, nil
+}
+
+func (r *OpenldapClusterReconciler) comparePodDisruptionBudget(
+	exists *policyv1.PodDisruptionBudget,
+	new *policyv1.PodDisruptionBudget,
+) bool {
+	if !utils.CompareMap(exists.Labels, new.Labels) {
+		return false
+	}
+
+	if !utils.CompareMap(exists.Annotations, new.Annotations) {
+		return false
+	}
+
+	if !reflect.DeepEqual(exists.Spec.Selector, new.Spec.Selector) {
+		return false
+	}
+
+	if !reflect.DeepEqual(exists.Spec.MinAvailable, new.Spec.MinAvailable) {
+		return false
+	}
+
+	return reflect.DeepEqual(exists.Spec.MaxUnavailable, new.Spec.MaxUnavailable)
+}
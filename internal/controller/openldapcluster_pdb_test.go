@@ -0,0 +1,64 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	openldapv1 "github.com/qwp0905/openldap-operator/api/v1"
+)
+
+var _ = Describe("PodDisruptionBudget", func() {
+	ctx := context.Background()
+
+	It("is created by default and deleted when disabled", func() {
+		reconciler, recorder := newTestReconciler()
+		cluster := createTestCluster(ctx, 3)
+
+		Expect(reconciler.ensurePodDisruptionBudget(ctx, cluster)).To(BeTrue())
+		Expect(recordedReasons(recorder)).To(ContainElement("PodDisruptionBudgetCreated"))
+		Expect(reconciler.ensurePodDisruptionBudget(ctx, cluster)).To(BeFalse())
+
+		cluster.Spec.PodDisruptionBudget.Enabled = false
+		Expect(reconciler.ensurePodDisruptionBudget(ctx, cluster)).To(BeTrue())
+		err := k8sClient.Get(ctx, client.ObjectKeyFromObject(cluster), &policyv1.PodDisruptionBudget{})
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+	})
+})
+
+var _ = Describe("Switchover on drain", func() {
+	ctx := context.Background()
+
+	It("moves the master off a cordoned node", func() {
+		reconciler, recorder := newTestReconciler()
+		cluster := createTestCluster(ctx, 3)
+		cluster.UpdateDesiredMaster(0)
+		cluster.UpdateCurrentMaster()
+		Expect(k8sClient.Status().Update(ctx, cluster)).To(Succeed())
+
+		masterPod := createTestPod(ctx, cluster, 0, cluster.SelectorLabels(), true)
+		scheduleTestPod(ctx, masterPod, createTestNode(ctx, "", false))
+		scheduleTestPod(ctx, createTestPod(ctx, cluster, 1, cluster.SelectorLabels(), true), createTestNode(ctx, "", true))
+		scheduleTestPod(ctx, createTestPod(ctx, cluster, 2, cluster.SelectorLabels(), true), createTestNode(ctx, "", false))
+
+		Expect(reconciler.switchoverOnDrain(ctx, cluster, masterPod)).To(BeFalse())
+
+		node := &corev1.Node{}
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Name: masterPod.Spec.NodeName}, node)).To(Succeed())
+		node.Spec.Unschedulable = true
+		Expect(k8sClient.Update(ctx, node)).To(Succeed())
+
+		Expect(reconciler.switchoverOnDrain(ctx, cluster, masterPod)).To(BeTrue())
+		Expect(recordedReasons(recorder)).To(ContainElement("MasterSwitchover"))
+
+		updated := getTestCluster(ctx, cluster)
+		Expect(updated.GetDesiredMaster()).To(Equal(cluster.PodName(2)))
+		Expect(updated.GetCondition(openldapv1.ConditionElected).Reason).
+			To(Equal(openldapv1.ReasonMasterNodeCordoned))
+	})
+})
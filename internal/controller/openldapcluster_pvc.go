@@ -0,0 +1,507 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	openldapv1 "github.com/qwp0905/openldap-operator/api/v1"
+	"github.com/qwp0905/openldap-operator/pkg/utils"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// ensureVolumeClaimTemplate handles a changed volume claim template, which is
+// immutable in the statefulset. A grown storage request is expanded on every
+// volume claim, other changes are kept out of the statefulset update and
+// reported by the VolumeExpansion condition.
+func (r *OpenldapClusterReconciler) ensureVolumeClaimTemplate(
+	ctx context.Context,
+	cluster *openldapv1.OpenldapCluster,
+	exists *appsv1.StatefulSet,
+	updated *appsv1.StatefulSet,
+) (bool, error) {
+	if isStorageClassChanged(exists, cluster) {
+		reason, message := migrationRefused(cluster)
+		if reason == "" {
+			return r.recreateStatefulset(ctx, cluster, exists, "storageClassName")
+		}
+
+		if err := r.updateStorageCondition(
+			ctx,
+			cluster,
+			openldapv1.ConditionStorageMigration,
+			false,
+			reason,
+			message,
+		); err != nil {
+			return false, err
+		}
+
+		updated.Spec.VolumeClaimTemplates = exists.Spec.VolumeClaimTemplates
+		return false, nil
+	}
+
+	existsTemplate := exists.Spec.VolumeClaimTemplates[0]
+	resized := *updated.Spec.VolumeClaimTemplates[0].DeepCopy()
+	resized.Spec.Resources.Requests = corev1.ResourceList{}
+	for name, quantity := range updated.Spec.VolumeClaimTemplates[0].Spec.Resources.Requests {
+		resized.Spec.Resources.Requests[name] = quantity
+	}
+	resized.Spec.Resources.Requests[corev1.ResourceStorage] =
+		existsTemplate.Spec.Resources.Requests[corev1.ResourceStorage]
+
+	size := cluster.StorageRequest()
+	if utils.ComparePVC(existsTemplate, resized) &&
+		size.Cmp(existsTemplate.Spec.Resources.Requests[corev1.ResourceStorage]) > 0 {
+		requeue, err := r.expandVolumes(ctx, cluster, exists)
+		if err != nil || requeue {
+			return requeue, err
+		}
+	} else if err := r.updateStorageCondition(
+		ctx,
+		cluster,
+		openldapv1.ConditionVolumeExpansion,
+		false,
+		openldapv1.ReasonTemplateImmutable,
+		fmt.Sprintf("Volume claim template of statefulset %s cannot be changed except the storage request", exists.Name),
+	); err != nil {
+		return false, err
+	}
+
+	updated.Spec.VolumeClaimTemplates = exists.Spec.VolumeClaimTemplates
+	return false, nil
+}
+
+// expandVolumes patches the storage request of the data volume claim of every
+// pod and waits for the file systems to be resized online. The statefulset is
+// recreated with orphan pods afterwards, so that the new template is adopted.
+func (r *OpenldapClusterReconciler) expandVolumes(
+	ctx context.Context,
+	cluster *openldapv1.OpenldapCluster,
+	statefulset *appsv1.StatefulSet,
+) (bool, error) {
+	logger := log.FromContext(ctx)
+	size := cluster.StorageRequest()
+
+	resized := 0
+	for i := 0; i < int(*statefulset.Spec.Replicas); i++ {
+		pvc, err := r.getDataVolumeClaim(ctx, cluster, i)
+		if err != nil {
+			if !errors.IsNotFound(err) {
+				logger.Error(err, "Error on getting PersistentVolumeClaim...")
+				return false, err
+			}
+
+			logger.Info(fmt.Sprintf("Waiting for PersistentVolumeClaim %s created", cluster.DataVolumeClaimName(i)))
+			continue
+		}
+
+		expandable, err := r.isVolumeExpandable(ctx, pvc)
+		if err != nil {
+			logger.Error(err, "Error on getting StorageClass...")
+			return false, err
+		}
+		if !expandable {
+			return false, r.updateStorageCondition(
+				ctx,
+				cluster,
+				openldapv1.ConditionVolumeExpansion,
+				false,
+				openldapv1.ReasonNotExpandable,
+				fmt.Sprintf("StorageClass of %s does not allow volume expansion", pvc.Name),
+			)
+		}
+
+		request := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+		if request.Cmp(size) < 0 {
+			origin := pvc.DeepCopy()
+			if pvc.Spec.Resources.Requests == nil {
+				pvc.Spec.Resources.Requests = corev1.ResourceList{}
+			}
+			pvc.Spec.Resources.Requests[corev1.ResourceStorage] = size
+			if err = r.Patch(ctx, pvc, client.MergeFrom(origin)); err != nil {
+				logger.Error(err, "Error on expanding PersistentVolumeClaim...")
+				return false, err
+			}
+
+			r.Recorder.Eventf(
+				cluster,
+				"Normal",
+				"VolumeExpanding",
+				"PersistentVolumeClaim %s expanding to %s",
+				pvc.Name,
+				size.String(),
+			)
+			continue
+		}
+
+		if isVolumeResized(pvc, size) {
+			resized++
+		}
+	}
+
+	if resized < int(*statefulset.Spec.Replicas) {
+		if err := r.updateStorageCondition(
+			ctx,
+			cluster,
+			openldapv1.ConditionVolumeExpansion,
+			true,
+			openldapv1.ReasonVolumeExpanding,
+			fmt.Sprintf("%d/%d volumes resized to %s", resized, *statefulset.Spec.Replicas, size.String()),
+		); err != nil {
+			return false, err
+		}
+
+		logger.Info("Waiting for volumes resized")
+		return true, nil
+	}
+
+	if err := r.updateStorageCondition(
+		ctx,
+		cluster,
+		openldapv1.ConditionVolumeExpansion,
+		false,
+		openldapv1.ReasonVolumeExpanded,
+		fmt.Sprintf("All volumes resized to %s", size.String()),
+	); err != nil {
+		return false, err
+	}
+
+	return r.recreateStatefulset(ctx, cluster, statefulset, "volumeClaimTemplates")
+}
+
+func (r *OpenldapClusterReconciler) isVolumeExpandable(
+	ctx context.Context,
+	pvc *corev1.PersistentVolumeClaim,
+) (bool, error) {
+	if pvc.Spec.StorageClassName == nil || *pvc.Spec.StorageClassName == "" {
+		return false, nil
+	}
+
+	storageClass := &storagev1.StorageClass{}
+	if err := r.Get(ctx, types.NamespacedName{Name: *pvc.Spec.StorageClassName}, storageClass); err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return storageClass.AllowVolumeExpansion != nil && *storageClass.AllowVolumeExpansion, nil
+}
+
+// isVolumeResized checks if the capacity of the claim reached the size and
+// its file system is not pending to be resized.
+func isVolumeResized(pvc *corev1.PersistentVolumeClaim, size resource.Quantity) bool {
+	capacity := pvc.Status.Capacity[corev1.ResourceStorage]
+	if capacity.Cmp(size) < 0 {
+		return false
+	}
+
+	for _, condition := range pvc.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+
+		if condition.Type == corev1.PersistentVolumeClaimResizing ||
+			condition.Type == corev1.PersistentVolumeClaimFileSystemResizePending {
+			return false
+		}
+	}
+
+	return true
+}
+
+// clearTemplateImmutableCondition removes the VolumeExpansion condition once
+// the volume claim template no longer differs from the statefulset.
+func (r *OpenldapClusterReconciler) clearTemplateImmutableCondition(
+	ctx context.Context,
+	cluster *openldapv1.OpenldapCluster,
+) error {
+	logger := log.FromContext(ctx)
+
+	if current := cluster.GetCondition(openldapv1.ConditionVolumeExpansion); current == nil ||
+		current.Reason != openldapv1.ReasonTemplateImmutable {
+		return nil
+	}
+
+	cluster.RemoveCondition(openldapv1.ConditionVolumeExpansion)
+	if err := r.Status().Update(ctx, cluster); err != nil {
+		logger.Error(err, "Error on Updating VolumeExpansion condition...")
+		return err
+	}
+
+	return nil
+}
+
+// migrationRefused returns the reason why the storage class cannot be
+// migrated, empty if it can. The resync of a rebuilt pod is only observed in
+// the operator monitor mode.
+func migrationRefused(cluster *openldapv1.OpenldapCluster) (string, string) {
+	if cluster.GetReplicas() == 1 {
+		return openldapv1.ReasonSingleReplica, "Storage class of a single replica cannot be migrated"
+	}
+
+	if !cluster.NativeMonitorEnabled() {
+		return openldapv1.ReasonMonitorDisabled, "Storage class is migrated only in the operator monitor mode"
+	}
+
+	return "", ""
+}
+
+// isStorageClassChanged checks if the storage class requested differs from
+// the one of the volume claim template of the statefulset.
+func isStorageClassChanged(statefulset *appsv1.StatefulSet, cluster *openldapv1.OpenldapCluster) bool {
+	if cluster.StorageClassName() == "" {
+		return false
+	}
+
+	exists := statefulset.Spec.VolumeClaimTemplates[0].Spec.StorageClassName
+	return exists == nil || *exists != cluster.StorageClassName()
+}
+
+func (r *OpenldapClusterReconciler) updateStorageCondition(
+	ctx context.Context,
+	cluster *openldapv1.OpenldapCluster,
+	conditionType string,
+	condition bool,
+	reason string,
+	message string,
+) error {
+	logger := log.FromContext(ctx)
+
+	if current := cluster.GetCondition(conditionType); current != nil &&
+		current.Reason == reason &&
+		current.Message == message {
+		return nil
+	}
+
+	cluster.SetCondition(conditionType, condition, reason, message)
+	if err := r.Status().Update(ctx, cluster); err != nil {
+		logger.Error(err, fmt.Sprintf("Error on Updating %s condition...", conditionType))
+		return err
+	}
+
+	return nil
+}
+
+func (r *OpenldapClusterReconciler) getDataVolumeClaim(
+	ctx context.Context,
+	cluster *openldapv1.OpenldapCluster,
+	index int,
+) (*corev1.PersistentVolumeClaim, error) {
+	pvc := &corev1.PersistentVolumeClaim{}
+
+	if err := r.Get(
+		ctx,
+		types.NamespacedName{Name: cluster.DataVolumeClaimName(index), Namespace: cluster.Namespace},
+		pvc,
+	); err != nil {
+		return nil, err
+	}
+
+	return pvc, nil
+}
+
+// migrateStorageClass rebuilds the pods whose data volume claim is not of
+// the requested storage class one at a time, so that each of them resyncs
+// from the master on a new volume claim. The next pod is only rebuilt once the
+// contextCSN of the previous one caught up. The master is switched over and
+// rebuilt last.
+func (r *OpenldapClusterReconciler) migrateStorageClass(
+	ctx context.Context,
+	cluster *openldapv1.OpenldapCluster,
+	masterPod *corev1.Pod,
+) (bool, error) {
+	logger := log.FromContext(ctx)
+
+	storageClass := cluster.StorageClassName()
+	if storageClass == "" || cluster.GetReplicas() == 1 {
+		return false, nil
+	}
+
+	// a migration in progress is paused once the monitor mode is changed
+	if !cluster.NativeMonitorEnabled() {
+		if current := cluster.GetCondition(openldapv1.ConditionStorageMigration); current == nil ||
+			current.Reason != openldapv1.ReasonStorageMigrating {
+			return false, nil
+		}
+
+		_, message := migrationRefused(cluster)
+		return false, r.updateStorageCondition(
+			ctx,
+			cluster,
+			openldapv1.ConditionStorageMigration,
+			false,
+			openldapv1.ReasonMonitorDisabled,
+			message,
+		)
+	}
+
+	migrated := 0
+	settling := false
+	masterPending := false
+	next := -1
+	for i := 0; i < cluster.GetReplicas(); i++ {
+		pvc, err := r.getDataVolumeClaim(ctx, cluster, i)
+		if err != nil {
+			if !errors.IsNotFound(err) {
+				logger.Error(err, "Error on getting PersistentVolumeClaim...")
+				return false, err
+			}
+
+			settling = true
+			continue
+		}
+
+		pod, err := r.getPod(ctx, cluster, i)
+		if err != nil && !errors.IsNotFound(err) {
+			logger.Error(err, "Error on getting pod...")
+			return false, err
+		}
+
+		if pvc.DeletionTimestamp != nil {
+			// the claim is kept while a pod still uses it
+			if pod != nil && pod.DeletionTimestamp == nil {
+				if err = r.Delete(ctx, pod); err != nil && !errors.IsNotFound(err) {
+					logger.Error(err, "Error on deleting pod of migrated volume...")
+					return false, err
+				}
+			}
+
+			settling = true
+			continue
+		}
+
+		if pvc.Spec.StorageClassName != nil && *pvc.Spec.StorageClassName == storageClass {
+			migrated++
+			if pod == nil || !utils.IsPodAlive(*pod) || !utils.IsPodReadyFor(*pod, switchBackDelay) ||
+				!isCaughtUp(cluster, pod.Name) {
+				settling = true
+			}
+			continue
+		}
+
+		if masterPod != nil && masterPod.Name == cluster.PodName(i) {
+			masterPending = true
+			continue
+		}
+
+		if next < 0 {
+			next = i
+		}
+	}
+
+	if migrated == cluster.GetReplicas() {
+		if current := cluster.GetCondition(openldapv1.ConditionStorageMigration); current == nil ||
+			current.Reason != openldapv1.ReasonStorageMigrating {
+			return false, nil
+		}
+
+		r.Recorder.Eventf(
+			cluster,
+			"Normal",
+			"StorageMigrated",
+			"All volumes migrated to storage class %s",
+			storageClass,
+		)
+		return false, r.updateStorageCondition(
+			ctx,
+			cluster,
+			openldapv1.ConditionStorageMigration,
+			false,
+			openldapv1.ReasonStorageMigrated,
+			fmt.Sprintf("All volumes use storage class %s", storageClass),
+		)
+	}
+
+	if err := r.updateStorageCondition(
+		ctx,
+		cluster,
+		openldapv1.ConditionStorageMigration,
+		true,
+		openldapv1.ReasonStorageMigrating,
+		fmt.Sprintf("%d/%d volumes migrated to storage class %s", migrated, cluster.GetReplicas(), storageClass),
+	); err != nil {
+		return false, err
+	}
+
+	if settling {
+		logger.Info("Waiting for rebuilt pod to resync")
+		return true, nil
+	}
+
+	if next >= 0 {
+		return true, r.rebuildPod(ctx, cluster, next, storageClass)
+	}
+
+	if !masterPending {
+		return true, nil
+	}
+
+	nextIndex, err := r.getAlivePodIndex(ctx, cluster, func(pod *corev1.Pod) (bool, error) {
+		pvc, err := r.getDataVolumeClaim(ctx, cluster, cluster.PodIndex(pod.Name))
+		if err != nil {
+			return true, nil
+		}
+
+		return pvc.Spec.StorageClassName == nil || *pvc.Spec.StorageClassName != storageClass, nil
+	})
+	if err != nil {
+		logger.Info("Cannot find migrated pod to switchover before rebuilding master")
+		return true, nil
+	}
+
+	return true, r.switchMaster(
+		ctx,
+		cluster,
+		masterPod,
+		nextIndex,
+		failoverReasonStorage,
+		openldapv1.ReasonMasterOnOldStorage,
+		fmt.Sprintf("Master %s is rebuilt on storage class %s", masterPod.Name, storageClass),
+	)
+}
+
+// rebuildPod deletes the data volume claim and the pod of the index, the
+// statefulset recreates both from the new volume claim template.
+func (r *OpenldapClusterReconciler) rebuildPod(
+	ctx context.Context,
+	cluster *openldapv1.OpenldapCluster,
+	index int,
+	storageClass string,
+) error {
+	logger := log.FromContext(ctx)
+
+	pvc := &corev1.PersistentVolumeClaim{}
+	pvc.Name = cluster.DataVolumeClaimName(index)
+	pvc.Namespace = cluster.Namespace
+	if err := r.Delete(ctx, pvc); err != nil && !errors.IsNotFound(err) {
+		logger.Error(err, "Error on deleting PersistentVolumeClaim...")
+		return err
+	}
+
+	pod := &corev1.Pod{}
+	pod.Name = cluster.PodName(index)
+	pod.Namespace = cluster.Namespace
+	if err := r.Delete(ctx, pod); err != nil && !errors.IsNotFound(err) {
+		logger.Error(err, "Error on deleting rebuilt pod...")
+		return err
+	}
+
+	r.Recorder.Eventf(
+		cluster,
+		"Normal",
+		"PodRebuilding",
+		"Pod %s rebuilding on storage class %s",
+		pod.Name,
+		storageClass,
+	)
+	return nil
+}
@@ -0,0 +1,195 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	openldapv1 "github.com/qwp0905/openldap-operator/api/v1"
+	"github.com/qwp0905/openldap-operator/pkg/statefulsets"
+)
+
+var _ = Describe("Volume expansion", func() {
+	ctx := context.Background()
+
+	// createTestStorageClass creates a storage class allowing expansion or not.
+	createTestStorageClass := func(expandable bool) string {
+		storageClass := &storagev1.StorageClass{
+			ObjectMeta:           metav1.ObjectMeta{Name: "sc-" + rand.String(6)},
+			Provisioner:          "example.com/disk",
+			AllowVolumeExpansion: &expandable,
+		}
+		Expect(k8sClient.Create(ctx, storageClass)).To(Succeed())
+
+		return storageClass.Name
+	}
+
+	// createTestClaim creates the bound data volume claim of the pod in the
+	// storage class.
+	createTestClaim := func(cluster *openldapv1.OpenldapCluster, index int, storageClassName string) {
+		pvc := &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: cluster.DataVolumeClaimName(index), Namespace: cluster.Namespace},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+				StorageClassName: &storageClassName,
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceStorage: cluster.StorageRequest()},
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, pvc)).To(Succeed())
+
+		pvc.Status.Phase = corev1.ClaimBound
+		Expect(k8sClient.Status().Update(ctx, pvc)).To(Succeed())
+	}
+
+	// createTestClaims creates the data volume claims of every pod in the
+	// storage class.
+	createTestClaims := func(cluster *openldapv1.OpenldapCluster, storageClassName string) {
+		for i := 0; i < cluster.GetReplicas(); i++ {
+			createTestClaim(cluster, i, storageClassName)
+		}
+	}
+
+	// createTestStatefulset creates the statefulset of the cluster as is, with
+	// the pull policy defaulted by the api server.
+	createTestStatefulset := func(r *OpenldapClusterReconciler, cluster *openldapv1.OpenldapCluster) {
+		cluster.Spec.Template.ImagePullPolicy = corev1.PullIfNotPresent
+		statefulset := statefulsets.CreateStatefulset(cluster.DeepCopy())
+		Expect(r.registerObject(cluster, statefulset)).To(Succeed())
+		Expect(k8sClient.Create(ctx, statefulset)).To(Succeed())
+	}
+
+	It("expands every volume claim and waits for the resize", func() {
+		r, recorder := newTestReconciler()
+		cluster := createTestCluster(ctx, 2)
+		cluster.SetDefault()
+		createTestClaims(cluster, createTestStorageClass(true))
+		createTestStatefulset(r, cluster)
+
+		cluster.Spec.Storage.VolumeClaimTemplate.Resources.Requests[corev1.ResourceStorage] = resource.MustParse("16Gi")
+		Expect(r.ensureStatefulset(ctx, cluster)).To(BeTrue())
+
+		for i := 0; i < cluster.GetReplicas(); i++ {
+			pvc, err := r.getDataVolumeClaim(ctx, cluster, i)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pvc.Spec.Resources.Requests.Storage().String()).To(Equal("16Gi"))
+		}
+		Expect(recordedReasons(recorder)).To(Equal([]string{"VolumeExpanding", "VolumeExpanding"}))
+
+		condition := cluster.GetCondition(openldapv1.ConditionVolumeExpansion)
+		Expect(condition.Reason).To(Equal(openldapv1.ReasonVolumeExpanding))
+		Expect(condition.Message).To(Equal("0/2 volumes resized to 16Gi"))
+	})
+
+	It("reports a storage class not allowing expansion", func() {
+		r, _ := newTestReconciler()
+		cluster := createTestCluster(ctx, 2)
+		cluster.SetDefault()
+		createTestClaims(cluster, createTestStorageClass(false))
+		createTestStatefulset(r, cluster)
+
+		cluster.Spec.Storage.VolumeClaimTemplate.Resources.Requests[corev1.ResourceStorage] = resource.MustParse("16Gi")
+		Expect(r.ensureStatefulset(ctx, cluster)).To(BeFalse())
+
+		condition := cluster.GetCondition(openldapv1.ConditionVolumeExpansion)
+		Expect(condition.Reason).To(Equal(openldapv1.ReasonNotExpandable))
+	})
+
+	It("reports other changes of the template until they are reverted", func() {
+		r, _ := newTestReconciler()
+		cluster := createTestCluster(ctx, 2)
+		cluster.SetDefault()
+		createTestStatefulset(r, cluster)
+
+		cluster.Spec.Storage.VolumeClaimTemplate.AccessModes = []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany}
+		_, err := r.ensureStatefulset(ctx, cluster)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cluster.GetCondition(openldapv1.ConditionVolumeExpansion).Reason).To(Equal(openldapv1.ReasonTemplateImmutable))
+
+		statefulset := statefulsets.CreateStatefulset(cluster.DeepCopy())
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(statefulset), statefulset)).To(Succeed())
+		Expect(statefulset.Spec.VolumeClaimTemplates[0].Spec.AccessModes).To(Equal(
+			[]corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+		))
+
+		cluster.Spec.Storage.VolumeClaimTemplate.AccessModes = []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}
+		_, err = r.ensureStatefulset(ctx, cluster)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cluster.GetCondition(openldapv1.ConditionVolumeExpansion)).To(BeNil())
+	})
+
+	Context("storage class migration", func() {
+		It("refuses the migration without the operator monitor", func() {
+			r, _ := newTestReconciler()
+			cluster := createTestCluster(ctx, 2)
+			cluster.SetDefault()
+			origin := createTestStorageClass(true)
+			cluster.Spec.Storage.VolumeClaimTemplate.StorageClassName = &origin
+			createTestStatefulset(r, cluster)
+
+			migrated := createTestStorageClass(true)
+			cluster.Spec.Storage.VolumeClaimTemplate.StorageClassName = &migrated
+			_, err := r.ensureStatefulset(ctx, cluster)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cluster.GetCondition(openldapv1.ConditionStorageMigration).Reason).To(
+				Equal(openldapv1.ReasonMonitorDisabled),
+			)
+
+			statefulset := statefulsets.CreateStatefulset(cluster.DeepCopy())
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(statefulset), statefulset)).To(Succeed())
+			Expect(*statefulset.Spec.VolumeClaimTemplates[0].Spec.StorageClassName).To(Equal(origin))
+		})
+
+		It("rebuilds the next pod once the migrated one caught up with the master", func() {
+			r, recorder := newTestReconciler()
+			cluster := createTestCluster(ctx, 3)
+			origin := createTestStorageClass(true)
+			migrated := createTestStorageClass(true)
+			// ldap-1 is already rebuilt on the new storage class
+			createTestClaim(cluster, 0, origin)
+			createTestClaim(cluster, 1, migrated)
+			createTestClaim(cluster, 2, origin)
+
+			cluster.Spec.Monitor.Enabled = true
+			cluster.Spec.Monitor.Mode = openldapv1.MonitorModeOperator
+			cluster.Spec.Storage.VolumeClaimTemplate.StorageClassName = &migrated
+			Expect(k8sClient.Update(ctx, cluster)).To(Succeed())
+
+			cluster.UpdateDesiredMaster(0)
+			cluster.UpdateCurrentMaster()
+			Expect(k8sClient.Status().Update(ctx, cluster)).To(Succeed())
+			cluster.SetDefault()
+
+			for i := 0; i < cluster.GetReplicas(); i++ {
+				createTestPod(ctx, cluster, i, cluster.SelectorLabels(), true)
+			}
+			masterPod, err := r.getPod(ctx, cluster, 0)
+			Expect(err).NotTo(HaveOccurred())
+
+			now := time.Now()
+			storeTestSnapshots(cluster, now, now.Add(-time.Second), now)
+			Expect(r.migrateStorageClass(ctx, cluster, masterPod)).To(BeTrue())
+			Expect(recordedReasons(recorder)).To(BeEmpty())
+			Expect(cluster.GetCondition(openldapv1.ConditionStorageMigration).Message).To(
+				Equal("1/3 volumes migrated to storage class " + migrated),
+			)
+
+			storeTestSnapshots(cluster, now, now, now)
+			Expect(r.migrateStorageClass(ctx, cluster, masterPod)).To(BeTrue())
+			Expect(recordedReasons(recorder)).To(Equal([]string{"PodRebuilding"}))
+
+			pvc, err := r.getDataVolumeClaim(ctx, cluster, 2)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pvc.DeletionTimestamp).NotTo(BeNil())
+		})
+	})
+})
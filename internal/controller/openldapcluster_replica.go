@@ -0,0 +1,254 @@
+package controller
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	openldapv1 "github.com/qwp0905/openldap-operator/api/v1"
+	"github.com/qwp0905/openldap-operator/pkg/monitors"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// replicationLag is the lag of a replica cluster last read by the observer.
+type replicationLag struct {
+	source     string
+	lagSeconds *int64
+	lastSync   time.Time
+}
+
+// replicationLags holds the last replicationLag of every replica cluster,
+// keyed by cluster.
+var replicationLags sync.Map
+
+// ensureReplicaSource resolves the provider url of a replica cluster into the
+// status before the statefulset is created, a referenced cluster is reached by
+// its write service.
+func (r *OpenldapClusterReconciler) ensureReplicaSource(
+	ctx context.Context,
+	cluster *openldapv1.OpenldapCluster,
+) (bool, error) {
+	logger := log.FromContext(ctx)
+
+	if !cluster.ReplicaEnabled() {
+		return false, nil
+	}
+
+	source := cluster.GetReplicaSource().Url
+	if ref := cluster.ReplicaSourceCluster(); ref != nil {
+		sourceCluster := &openldapv1.OpenldapCluster{}
+
+		if err := r.Get(
+			ctx,
+			types.NamespacedName{Name: ref.Name, Namespace: ref.Namespace},
+			sourceCluster,
+		); err != nil {
+			if !errors.IsNotFound(err) {
+				logger.Error(err, "Error on getting source cluster...")
+				return false, err
+			}
+
+			r.Recorder.Eventf(
+				cluster,
+				"Warning",
+				"ReplicaSourceNotFound",
+				"Source cluster %s/%s not found",
+				ref.Namespace,
+				ref.Name,
+			)
+			return true, nil
+		}
+
+		source = sourceCluster.WriteServiceUrl()
+	}
+
+	if cluster.Status.Replication != nil && cluster.Status.Replication.Source == source {
+		return false, nil
+	}
+
+	replication := cluster.GetReplicationStatus()
+	replication.Source = source
+	replication.LagSeconds = nil
+	replication.LastSyncTime = nil
+
+	if err := r.Status().Update(ctx, cluster); err != nil {
+		logger.Error(err, "Error on Updating Replica source...")
+		return false, err
+	}
+
+	r.Recorder.Eventf(
+		cluster,
+		"Normal",
+		"ReplicaSourceResolved",
+		"Cluster replicates from %s",
+		source,
+	)
+	return true, nil
+}
+
+// updateReplicationLag sets the lag last read by the observer from the
+// current source, the status is kept until the first one is read.
+func (r *OpenldapClusterReconciler) updateReplicationLag(cluster *openldapv1.OpenldapCluster) {
+	if !cluster.ReplicaEnabled() {
+		return
+	}
+
+	value, ok := replicationLags.Load(clusterKey(cluster))
+	if !ok {
+		return
+	}
+
+	observed := value.(replicationLag)
+	replication := cluster.GetReplicationStatus()
+	if observed.source != replication.Source {
+		return
+	}
+
+	replication.LagSeconds = nil
+	if observed.lagSeconds != nil {
+		seconds := *observed.lagSeconds
+		replication.LagSeconds = &seconds
+	}
+	if !observed.lastSync.IsZero() &&
+		(replication.LastSyncTime == nil || !replication.LastSyncTime.Time.Equal(observed.lastSync)) {
+		replication.LastSyncTime = &metav1.Time{Time: observed.lastSync}
+	}
+}
+
+// observeReplicationLag compares the contextCSN of the source with the one of
+// every pod of a replica cluster. Failures are only logged and reset the lag,
+// so that an unreachable source is reported by the replication condition.
+func (r *OpenldapClusterReconciler) observeReplicationLag(
+	ctx context.Context,
+	cluster *openldapv1.OpenldapCluster,
+) {
+	logger := log.FromContext(ctx)
+
+	if !cluster.ReplicaEnabled() {
+		replicationLags.Delete(clusterKey(cluster))
+		return
+	}
+
+	observed := replicationLag{source: cluster.ReplicaSourceUrl()}
+	if value, ok := replicationLags.Load(clusterKey(cluster)); ok && value.(replicationLag).source == observed.source {
+		observed.lastSync = value.(replicationLag).lastSync
+	}
+	defer func() { replicationLags.Store(clusterKey(cluster), observed) }()
+
+	sourceCsn, err := r.readSourceContextCsn(ctx, cluster)
+	if err != nil {
+		logger.V(1).Info(fmt.Sprintf("Reading contextCSN of source failed: %s", err))
+		return
+	}
+
+	password, err := r.getSecretValue(ctx, cluster, cluster.ExporterBindPassword())
+	if err != nil {
+		logger.Error(err, "Error on getting bind password...")
+		return
+	}
+
+	tlsConfig, err := r.getMonitorTlsConfig(ctx, cluster)
+	if err != nil {
+		logger.Error(err, "Error on getting monitor tls config...")
+		return
+	}
+
+	var lag time.Duration
+	var lastSync time.Time
+	for i := 0; i < cluster.GetReplicas(); i++ {
+		pod, err := r.getPod(ctx, cluster, i)
+		if err != nil || pod.Status.PodIP == "" {
+			return
+		}
+
+		var podTlsConfig *tls.Config
+		if tlsConfig != nil {
+			podTlsConfig = tlsConfig.Clone()
+			podTlsConfig.ServerName = cluster.PodDnsName(i)
+		}
+
+		podCsn, err := monitors.ReadContextCsn(monitors.ScrapeTarget{
+			Url: fmt.Sprintf(
+				"%s://%s:%s",
+				cluster.ServingScheme(),
+				pod.Status.PodIP,
+				strconv.Itoa(int(cluster.ServingPort())),
+			),
+			TlsConfig:    podTlsConfig,
+			StartTls:     cluster.StartTlsRequired(),
+			BindDn:       cluster.ExporterBindDn(),
+			BindPassword: password,
+			Root:         cluster.ReplicaSearchBase(),
+		})
+		if err != nil {
+			logger.V(1).Info(fmt.Sprintf("Reading contextCSN of %s failed: %s", pod.Name, err))
+			return
+		}
+
+		var synced time.Time
+		for serverId, changedAt := range sourceCsn {
+			if behind := changedAt.Sub(podCsn[serverId]); behind > lag {
+				lag = behind
+			}
+			if podCsn[serverId].After(synced) {
+				synced = podCsn[serverId]
+			}
+		}
+
+		if i == 0 || synced.Before(lastSync) {
+			lastSync = synced
+		}
+	}
+
+	seconds := int64(lag.Seconds())
+	observed.lagSeconds = &seconds
+	if !lastSync.IsZero() {
+		observed.lastSync = lastSync
+	}
+}
+
+func (r *OpenldapClusterReconciler) readSourceContextCsn(
+	ctx context.Context,
+	cluster *openldapv1.OpenldapCluster,
+) (map[string]time.Time, error) {
+	source := cluster.GetReplicaSource()
+	url := cluster.ReplicaSourceUrl()
+	if url == "" {
+		return nil, fmt.Errorf("source of replica not resolved")
+	}
+
+	password, err := r.getSecretValue(ctx, cluster, source.BindPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	var tlsConfig *tls.Config
+	if source.Ca != nil {
+		ca, err := r.getSecretValue(ctx, cluster, source.Ca)
+		if err != nil {
+			return nil, err
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(ca)) {
+			return nil, fmt.Errorf("no certificate found in %s", source.Ca.Key)
+		}
+
+		tlsConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+
+	return monitors.ReadContextCsn(monitors.ScrapeTarget{
+		Url:          url,
+		TlsConfig:    tlsConfig,
+		BindDn:       source.BindDn,
+		BindPassword: password,
+		Root:         cluster.ReplicaSearchBase(),
+	})
+}
@@ -0,0 +1,100 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/rand"
+
+	openldapv1 "github.com/qwp0905/openldap-operator/api/v1"
+	"github.com/qwp0905/openldap-operator/internal/fixtures"
+)
+
+var _ = Describe("Replica cluster", func() {
+	ctx := context.Background()
+
+	It("keeps the cluster without master until promoted", func() {
+		reconciler, _ := newTestReconciler()
+		cluster := fixtures.WithReplica(createTestCluster(ctx, 3), "ldap://ldap.example.com")
+		Expect(k8sClient.Update(ctx, cluster)).To(Succeed())
+		cluster.SetInitCondition()
+		cluster.Status.CurrentMaster = cluster.PodName(0)
+
+		_, err := reconciler.updateReplicaStatus(ctx, cluster)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cluster.GetCurrentMaster()).To(BeEmpty())
+		Expect(cluster.GetCondition(openldapv1.ConditionElected).Reason).To(Equal(openldapv1.ReasonReplicaOfSource))
+		Expect(cluster.GetCondition(openldapv1.ConditionReady).Reason).To(Equal(openldapv1.ReasonNoHealthyPod))
+
+		cluster.Status.ReadyReplicas = 1
+		_, err = reconciler.updateReplicaStatus(ctx, cluster)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cluster.GetCondition(openldapv1.ConditionInitialized)).To(BeNil())
+		Expect(cluster.GetCondition(openldapv1.ConditionReady).Reason).To(Equal(openldapv1.ReasonReplicaOfSource))
+		Expect(cluster.GetPhase()).To(Equal(openldapv1.PhaseReplica))
+	})
+
+	It("resolves a referenced cluster to its write service", func() {
+		reconciler, recorder := newTestReconciler()
+		cluster := fixtures.WithReplica(createTestCluster(ctx, 3), "")
+		cluster.Spec.Replica.Source.Cluster = &openldapv1.ClusterReference{Name: cluster.Name + "-source"}
+		Expect(k8sClient.Update(ctx, cluster)).To(Succeed())
+
+		requeue, err := reconciler.ensureReplicaSource(ctx, cluster)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(requeue).To(BeTrue())
+		Expect(recordedReasons(recorder)).To(ConsistOf("ReplicaSourceNotFound"))
+
+		source := fixtures.NewCluster(cluster.Name+"-source", 3)
+		Expect(k8sClient.Create(ctx, source)).To(Succeed())
+
+		requeue, err = reconciler.ensureReplicaSource(ctx, cluster)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(requeue).To(BeTrue())
+		Expect(recordedReasons(recorder)).To(ConsistOf("ReplicaSourceResolved"))
+		Expect(cluster.ReplicaSourceUrl()).To(Equal(source.WriteServiceUrl()))
+
+		requeue, err = reconciler.ensureReplicaSource(ctx, cluster)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(requeue).To(BeFalse())
+	})
+
+	It("reports the lag observed from the current source", func() {
+		cluster := fixtures.WithReplica(fixtures.NewCluster("ldap-"+rand.String(6), 3), "ldap://ldap.example.com")
+		cluster.GetReplicationStatus().Source = cluster.ReplicaSourceUrl()
+		DeferCleanup(replicationLags.Delete, clusterKey(cluster))
+
+		reconciler := &OpenldapClusterReconciler{}
+		reconciler.updateReplicationLag(cluster)
+		reconciler.updateReplicationCondition(cluster)
+		Expect(cluster.GetCondition(openldapv1.ConditionReplicationHealthy).Status).To(Equal(metav1.ConditionUnknown))
+
+		lag := int64(90)
+		replicationLags.Store(clusterKey(cluster), replicationLag{
+			source:     "ldap://other",
+			lagSeconds: &lag,
+		})
+		reconciler.updateReplicationLag(cluster)
+		Expect(cluster.GetReplicationStatus().LagSeconds).To(BeNil())
+
+		lastSync := time.Now().Truncate(time.Second)
+		replicationLags.Store(clusterKey(cluster), replicationLag{
+			source:     cluster.ReplicaSourceUrl(),
+			lagSeconds: &lag,
+			lastSync:   lastSync,
+		})
+		reconciler.updateReplicationLag(cluster)
+		reconciler.updateReplicationCondition(cluster)
+		Expect(*cluster.GetReplicationStatus().LagSeconds).To(Equal(lag))
+		Expect(cluster.GetReplicationStatus().LastSyncTime.Time).To(Equal(lastSync))
+		Expect(cluster.GetCondition(openldapv1.ConditionReplicationHealthy).Reason).To(Equal(openldapv1.ReasonReplicationLagging))
+
+		lag = 30
+		reconciler.updateReplicationLag(cluster)
+		reconciler.updateReplicationCondition(cluster)
+		Expect(cluster.GetCondition(openldapv1.ConditionReplicationHealthy).Reason).To(Equal(openldapv1.ReasonReplicationInSync))
+	})
+})
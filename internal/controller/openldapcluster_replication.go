@@ -0,0 +1,397 @@
+package controller
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	openldapv1 "github.com/qwp0905/openldap-operator/api/v1"
+	"github.com/qwp0905/openldap-operator/pkg/monitors"
+	"github.com/qwp0905/openldap-operator/pkg/replication"
+	"github.com/qwp0905/openldap-operator/pkg/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// replicationConfigAnnotation holds the hash of the replication config last
+// written to the pod, a restarted pod is configured again.
+const replicationConfigAnnotation = "openldap.kwonjin.click/replication-config"
+
+// configReplicationStatuses holds the last cn=config replication status read
+// by the observer, keyed by cluster.
+var configReplicationStatuses sync.Map
+
+// configureReplication writes the syncrepl config of every ready pod whose
+// rendered config changed, e.g. once the master changed. It runs in the
+// observer apart from the reconciliation, failures are only logged and
+// recorded as event.
+func (r *OpenldapClusterReconciler) configureReplication(
+	ctx context.Context,
+	cluster *openldapv1.OpenldapCluster,
+) {
+	logger := log.FromContext(ctx)
+
+	if !cluster.ReplicationManaged() {
+		return
+	}
+
+	// consumers of a single master wait for the master to be elected
+	if !cluster.ReplicaEnabled() &&
+		!cluster.IsMultiProvider() &&
+		(cluster.GetCurrentMaster() == "" || !cluster.IsMasterSame()) {
+		return
+	}
+
+	configPassword, err := r.getSecretValue(ctx, cluster, cluster.Spec.OpenldapConfig.ConfigPassword)
+	if err != nil {
+		logger.Error(err, "Error on getting config password...")
+		return
+	}
+
+	providers, err := r.getReplicationProviders(ctx, cluster)
+	if err != nil {
+		logger.Error(err, "Error on getting replication providers...")
+		return
+	}
+
+	tlsConfig, err := r.getMonitorTlsConfig(ctx, cluster)
+	if err != nil {
+		logger.Error(err, "Error on getting monitor tls config...")
+		return
+	}
+
+	// the seed is configured first, the others consume cn=config from it only
+	// once its overlays exist
+	seed := configSeedIndex(cluster)
+	order := []int{seed}
+	for i := 0; i < cluster.GetReplicas(); i++ {
+		if i != seed {
+			order = append(order, i)
+		}
+	}
+
+	seedConfigured := false
+	for _, i := range order {
+		if i != seed && cluster.ConfigReplicationEnabled() && !seedConfigured {
+			break
+		}
+
+		pod, err := r.getPod(ctx, cluster, i)
+		if err != nil || pod.Status.PodIP == "" || !utils.IsPodAlive(*pod) || !utils.IsPodReady(*pod) {
+			continue
+		}
+
+		podProviders, mirrorMode := providers(i)
+		config := replication.CreatePodConfig(cluster, podProviders, mirrorMode)
+		if cluster.ConfigReplicationEnabled() {
+			config.SetConfigReplication(cluster, getConfigSeed(cluster, configPassword, i, seed))
+		}
+		hash := config.Hash()
+		if pod.Annotations[replicationConfigAnnotation] == hash {
+			seedConfigured = seedConfigured || i == seed
+			continue
+		}
+
+		var podTlsConfig *tls.Config
+		if tlsConfig != nil {
+			podTlsConfig = tlsConfig.Clone()
+			podTlsConfig.ServerName = cluster.PodDnsName(i)
+		}
+
+		if err = replication.Apply(replication.Target{
+			Url: fmt.Sprintf(
+				"%s://%s:%s",
+				cluster.ServingScheme(),
+				pod.Status.PodIP,
+				strconv.Itoa(int(cluster.ServingPort())),
+			),
+			TlsConfig:    podTlsConfig,
+			StartTls:     cluster.StartTlsRequired(),
+			BindDn:       cluster.ConfigAdminDn(),
+			BindPassword: configPassword,
+		}, config); err != nil {
+			logger.Error(err, fmt.Sprintf("Error on configuring replication of %s...", pod.Name))
+			r.Recorder.Eventf(
+				cluster,
+				"Warning",
+				"ReplicationConfigFailed",
+				"Replication config of %s failed: %s",
+				pod.Name,
+				err,
+			)
+			continue
+		}
+
+		origin := pod.DeepCopy()
+		pod.SetAnnotations(utils.MergeMap(
+			pod.GetAnnotations(),
+			map[string]string{replicationConfigAnnotation: hash},
+		))
+		if err = r.Patch(ctx, pod, client.MergeFrom(origin)); err != nil {
+			logger.Error(err, "Error on annotating replication config...")
+			continue
+		}
+		seedConfigured = seedConfigured || i == seed
+
+		r.Recorder.Eventf(
+			cluster,
+			"Normal",
+			"ReplicationConfigured",
+			"Replication config of %s updated with %d providers",
+			pod.Name,
+			len(podProviders),
+		)
+	}
+}
+
+// getReplicationProviders returns the providers each pod consumes from and
+// whether the pod runs in mirror mode. Pods of a replica cluster consume from
+// the source, providers of a multi-provider cluster from each other and
+// consumers of a single master cluster from the current master.
+func (r *OpenldapClusterReconciler) getReplicationProviders(
+	ctx context.Context,
+	cluster *openldapv1.OpenldapCluster,
+) (func(index int) ([]replication.Provider, bool), error) {
+	if cluster.ReplicaEnabled() {
+		source := cluster.GetReplicaSource()
+		password, err := r.getSecretValue(ctx, cluster, source.BindPassword)
+		if err != nil {
+			return nil, err
+		}
+
+		provider := replication.Provider{
+			Url:         cluster.ReplicaSourceUrl(),
+			BindDn:      source.BindDn,
+			Credentials: password,
+		}
+		if source.Ca != nil {
+			provider.CaFile = fmt.Sprintf("%s/%s", cluster.ReplicaCaMountPath(), source.Ca.Key)
+		}
+
+		return func(int) ([]replication.Provider, bool) {
+			return []replication.Provider{provider}, false
+		}, nil
+	}
+
+	password, err := r.getSecretValue(ctx, cluster, cluster.Spec.OpenldapConfig.AdminPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	podProvider := func(index int) replication.Provider {
+		provider := replication.Provider{
+			Url:         cluster.PodUrl(index),
+			BindDn:      cluster.AdminDn(),
+			Credentials: password,
+			StartTls:    cluster.StartTlsRequired(),
+		}
+		if cluster.LdapsOnly() || cluster.RequireTls() {
+			provider.CaFile = fmt.Sprintf(
+				"%s/%s",
+				cluster.TlsMountPath(),
+				cluster.Spec.OpenldapConfig.Tls.CaFile,
+			)
+		}
+
+		return provider
+	}
+
+	if cluster.IsMultiProvider() {
+		return func(index int) ([]replication.Provider, bool) {
+			providers := []replication.Provider{}
+			for i := 0; i < cluster.GetReplicas(); i++ {
+				if i != index && cluster.IsProvider(i) {
+					providers = append(providers, podProvider(i))
+				}
+			}
+
+			return providers, cluster.IsProvider(index)
+		}, nil
+	}
+
+	master := cluster.PodIndex(cluster.GetCurrentMaster())
+	if master < 0 {
+		return nil, fmt.Errorf("master %s is not a pod of the cluster", cluster.GetCurrentMaster())
+	}
+
+	return func(index int) ([]replication.Provider, bool) {
+		if index == master {
+			return []replication.Provider{}, false
+		}
+
+		return []replication.Provider{podProvider(master)}, false
+	}, nil
+}
+
+// configSeedIndex returns the pod cn=config is replicated from, the current
+// master or the first pod before any is elected.
+func configSeedIndex(cluster *openldapv1.OpenldapCluster) int {
+	if index := cluster.PodIndex(cluster.GetCurrentMaster()); index >= 0 {
+		return index
+	}
+
+	return 0
+}
+
+// getConfigSeed returns the seed the pod consumes cn=config from, nil for the
+// seed itself.
+func getConfigSeed(
+	cluster *openldapv1.OpenldapCluster,
+	configPassword string,
+	index int,
+	seed int,
+) *replication.Provider {
+	if index == seed {
+		return nil
+	}
+
+	provider := &replication.Provider{
+		Url:         cluster.PodUrl(seed),
+		BindDn:      cluster.ConfigAdminDn(),
+		Credentials: configPassword,
+		StartTls:    cluster.StartTlsRequired(),
+	}
+	if cluster.LdapsOnly() || cluster.RequireTls() {
+		provider.CaFile = fmt.Sprintf(
+			"%s/%s",
+			cluster.TlsMountPath(),
+			cluster.Spec.OpenldapConfig.Tls.CaFile,
+		)
+	}
+
+	return provider
+}
+
+// updateConfigReplicationStatus sets the config replication status last read
+// by the observer, the status is kept until the first one is read.
+func (r *OpenldapClusterReconciler) updateConfigReplicationStatus(cluster *openldapv1.OpenldapCluster) {
+	if !cluster.ConfigReplicationEnabled() {
+		cluster.Status.ConfigReplication = nil
+		return
+	}
+
+	value, ok := configReplicationStatuses.Load(clusterKey(cluster))
+	if !ok {
+		return
+	}
+
+	status := value.(*openldapv1.ConfigReplicationStatus).DeepCopy()
+	if status.LastChangeTime != nil &&
+		cluster.Status.ConfigReplication != nil &&
+		cluster.Status.ConfigReplication.LastChangeTime != nil &&
+		cluster.Status.ConfigReplication.LastChangeTime.Time.Equal(status.LastChangeTime.Time) {
+		status.LastChangeTime = cluster.Status.ConfigReplication.LastChangeTime
+	}
+
+	cluster.Status.ConfigReplication = status
+}
+
+// observeConfigReplication compares the contextCSN of cn=config of every pod,
+// pods without the newest change or not reachable are pending.
+func (r *OpenldapClusterReconciler) observeConfigReplication(
+	ctx context.Context,
+	cluster *openldapv1.OpenldapCluster,
+) {
+	logger := log.FromContext(ctx)
+
+	if !cluster.ConfigReplicationEnabled() {
+		configReplicationStatuses.Delete(clusterKey(cluster))
+		return
+	}
+
+	configPassword, err := r.getSecretValue(ctx, cluster, cluster.Spec.OpenldapConfig.ConfigPassword)
+	if err != nil {
+		logger.Error(err, "Error on getting config password...")
+		return
+	}
+
+	tlsConfig, err := r.getMonitorTlsConfig(ctx, cluster)
+	if err != nil {
+		logger.Error(err, "Error on getting monitor tls config...")
+		return
+	}
+
+	podCsns := map[string]map[string]time.Time{}
+	newest := map[string]time.Time{}
+	pending := []string{}
+	for i := 0; i < cluster.GetReplicas(); i++ {
+		pod, err := r.getPod(ctx, cluster, i)
+		if err != nil || pod.Status.PodIP == "" {
+			pending = append(pending, cluster.PodName(i))
+			continue
+		}
+
+		var podTlsConfig *tls.Config
+		if tlsConfig != nil {
+			podTlsConfig = tlsConfig.Clone()
+			podTlsConfig.ServerName = cluster.PodDnsName(i)
+		}
+
+		csns, err := monitors.ReadContextCsn(monitors.ScrapeTarget{
+			Url: fmt.Sprintf(
+				"%s://%s:%s",
+				cluster.ServingScheme(),
+				pod.Status.PodIP,
+				strconv.Itoa(int(cluster.ServingPort())),
+			),
+			TlsConfig:    podTlsConfig,
+			StartTls:     cluster.StartTlsRequired(),
+			BindDn:       cluster.ConfigAdminDn(),
+			BindPassword: configPassword,
+			Root:         "cn=config",
+		})
+		if err != nil {
+			logger.V(1).Info(fmt.Sprintf("Reading contextCSN of cn=config of %s failed: %s", pod.Name, err))
+			pending = append(pending, pod.Name)
+			continue
+		}
+
+		podCsns[pod.Name] = csns
+		for serverId, changedAt := range csns {
+			if changedAt.After(newest[serverId]) {
+				newest[serverId] = changedAt
+			}
+		}
+	}
+
+	converged := []string{}
+	var lastChange time.Time
+	for i := 0; i < cluster.GetReplicas(); i++ {
+		csns, ok := podCsns[cluster.PodName(i)]
+		if !ok {
+			continue
+		}
+
+		behind := false
+		for serverId, changedAt := range newest {
+			if csns[serverId].Before(changedAt) {
+				behind = true
+			}
+			if changedAt.After(lastChange) {
+				lastChange = changedAt
+			}
+		}
+
+		if behind {
+			pending = append(pending, cluster.PodName(i))
+		} else {
+			converged = append(converged, cluster.PodName(i))
+		}
+	}
+	sort.Strings(pending)
+
+	status := &openldapv1.ConfigReplicationStatus{
+		ConvergedPods: converged,
+		PendingPods:   pending,
+	}
+	if !lastChange.IsZero() {
+		status.LastChangeTime = &metav1.Time{Time: lastChange}
+	}
+
+	configReplicationStatuses.Store(clusterKey(cluster), status)
+}
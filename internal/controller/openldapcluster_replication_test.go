@@ -0,0 +1,170 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	openldapv1 "github.com/qwp0905/openldap-operator/api/v1"
+	"github.com/qwp0905/openldap-operator/internal/fixtures"
+)
+
+var _ = Describe("Replication", func() {
+	ctx := context.Background()
+
+	// createAdminSecret creates the admin and config passwords of the cluster.
+	createAdminSecret := func(cluster *openldapv1.OpenldapCluster) {
+		Expect(k8sClient.Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: cluster.Name, Namespace: cluster.Namespace},
+			Data: map[string][]byte{
+				"admin":  []byte("admin"),
+				"config": []byte("config"),
+			},
+		})).To(Succeed())
+	}
+
+	It("consumes from every other provider of a multi-provider cluster", func() {
+		reconciler, _ := newTestReconciler()
+		cluster := fixtures.WithTopology(createTestCluster(ctx, 3), openldapv1.TopologyMultiProvider)
+		createAdminSecret(cluster)
+
+		providersOf, err := reconciler.getReplicationProviders(ctx, cluster)
+		Expect(err).NotTo(HaveOccurred())
+
+		providers, mirrorMode := providersOf(1)
+		Expect(mirrorMode).To(BeTrue())
+		Expect(providers).To(HaveLen(2))
+		Expect(providers[0].Url).To(Equal(cluster.PodUrl(0)))
+		Expect(providers[1].Url).To(Equal(cluster.PodUrl(2)))
+		Expect(providers[0].BindDn).To(Equal(cluster.AdminDn()))
+		Expect(providers[0].Credentials).To(Equal("admin"))
+	})
+
+	It("consumes from both providers of a mirror mode cluster", func() {
+		reconciler, _ := newTestReconciler()
+		cluster := fixtures.WithTopology(createTestCluster(ctx, 3), openldapv1.TopologyMirrorMode)
+		createAdminSecret(cluster)
+
+		providersOf, err := reconciler.getReplicationProviders(ctx, cluster)
+		Expect(err).NotTo(HaveOccurred())
+
+		providers, mirrorMode := providersOf(0)
+		Expect(mirrorMode).To(BeTrue())
+		Expect(providers).To(HaveLen(1))
+		Expect(providers[0].Url).To(Equal(cluster.PodUrl(1)))
+
+		providers, mirrorMode = providersOf(2)
+		Expect(mirrorMode).To(BeFalse())
+		Expect(providers).To(HaveLen(2))
+	})
+
+	It("verifies the providers with the ca of the cluster if tls is required", func() {
+		reconciler, _ := newTestReconciler()
+		cluster := fixtures.WithTopology(
+			fixtures.WithTls(createTestCluster(ctx, 2)),
+			openldapv1.TopologyMirrorMode,
+		)
+		cluster.Spec.OpenldapConfig.Tls.RequireTls = true
+		createAdminSecret(cluster)
+
+		providersOf, err := reconciler.getReplicationProviders(ctx, cluster)
+		Expect(err).NotTo(HaveOccurred())
+
+		providers, _ := providersOf(0)
+		Expect(providers[0].StartTls).To(BeTrue())
+		Expect(providers[0].CaFile).To(Equal(cluster.TlsMountPath() + "/" + cluster.Spec.OpenldapConfig.Tls.CaFile))
+	})
+
+	It("consumes from the current master of a single master cluster", func() {
+		reconciler, _ := newTestReconciler()
+		cluster := createTestCluster(ctx, 3)
+		createAdminSecret(cluster)
+
+		for _, master := range []string{"", "other-0"} {
+			cluster.Status.CurrentMaster = master
+			_, err := reconciler.getReplicationProviders(ctx, cluster)
+			Expect(err).To(HaveOccurred())
+		}
+
+		cluster.Status.CurrentMaster = cluster.PodName(1)
+		providersOf, err := reconciler.getReplicationProviders(ctx, cluster)
+		Expect(err).NotTo(HaveOccurred())
+
+		providers, mirrorMode := providersOf(1)
+		Expect(providers).To(BeEmpty())
+		Expect(mirrorMode).To(BeFalse())
+
+		providers, _ = providersOf(0)
+		Expect(providers).To(HaveLen(1))
+		Expect(providers[0].Url).To(Equal(cluster.PodUrl(1)))
+	})
+
+	It("consumes from the source of a replica cluster", func() {
+		reconciler, _ := newTestReconciler()
+		cluster := fixtures.WithReplica(createTestCluster(ctx, 3), "ldaps://ldap.example.com")
+		cluster.Spec.Replica.Source.Ca = &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "source-ca"},
+			Key:                  "ca.crt",
+		}
+		Expect(k8sClient.Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: cluster.Name, Namespace: cluster.Namespace},
+			Data:       map[string][]byte{"replica": []byte("replica")},
+		})).To(Succeed())
+
+		providersOf, err := reconciler.getReplicationProviders(ctx, cluster)
+		Expect(err).NotTo(HaveOccurred())
+
+		providers, _ := providersOf(2)
+		Expect(providers).To(HaveLen(1))
+		Expect(providers[0].Url).To(Equal("ldaps://ldap.example.com"))
+		Expect(providers[0].Credentials).To(Equal("replica"))
+		Expect(providers[0].CaFile).To(Equal(cluster.ReplicaCaMountPath() + "/ca.crt"))
+	})
+
+	It("seeds cn=config from the current master", func() {
+		cluster := fixtures.NewCluster("ldap", 3)
+		Expect(configSeedIndex(cluster)).To(Equal(0))
+
+		cluster.Status.CurrentMaster = cluster.PodName(2)
+		Expect(configSeedIndex(cluster)).To(Equal(2))
+
+		Expect(getConfigSeed(cluster, "config", 2, 2)).To(BeNil())
+
+		seed := getConfigSeed(cluster, "config", 0, 2)
+		Expect(seed.Url).To(Equal(cluster.PodUrl(2)))
+		Expect(seed.BindDn).To(Equal(cluster.ConfigAdminDn()))
+		Expect(seed.Credentials).To(Equal("config"))
+	})
+
+	It("reads the cn=config replication status of the observer", func() {
+		reconciler, _ := newTestReconciler()
+		cluster := fixtures.NewCluster("ldap", 3)
+		cluster.Spec.Replication.Enabled = true
+		cluster.Spec.Replication.ConfigDatabase = true
+		DeferCleanup(func() { configReplicationStatuses.Delete(clusterKey(cluster)) })
+
+		reconciler.updateConfigReplicationStatus(cluster)
+		Expect(cluster.Status.ConfigReplication).To(BeNil())
+
+		configReplicationStatuses.Store(clusterKey(cluster), &openldapv1.ConfigReplicationStatus{
+			ConvergedPods: []string{cluster.PodName(0), cluster.PodName(2)},
+			PendingPods:   []string{cluster.PodName(1)},
+		})
+		reconciler.updateConfigReplicationStatus(cluster)
+		Expect(cluster.Status.ConfigReplication.PendingPods).To(Equal([]string{cluster.PodName(1)}))
+
+		reconciler.updateConfigReplicatedCondition(cluster)
+		condition := cluster.GetCondition(openldapv1.ConditionConfigReplicated)
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition.Reason).To(Equal(openldapv1.ReasonConfigNotConverged))
+
+		cluster.Spec.Replication.ConfigDatabase = false
+		reconciler.updateConfigReplicationStatus(cluster)
+		reconciler.updateConfigReplicatedCondition(cluster)
+		Expect(cluster.Status.ConfigReplication).To(BeNil())
+		Expect(cluster.GetCondition(openldapv1.ConditionConfigReplicated)).To(BeNil())
+	})
+})
@@ -2,6 +2,7 @@ package controller
 
 import (
 	"context"
+	"reflect"
 
 	openldapv1 "github.com/qwp0905/openldap-operator/api/v1"
 	"github.com/qwp0905/openldap-operator/pkg/rbac"
@@ -56,6 +57,7 @@ func (r *OpenldapClusterReconciler) ensureRole(
 
 	existsRole.SetAnnotations(updatedRole.GetAnnotations())
 	existsRole.SetLabels(updatedRole.GetLabels())
+	existsRole.Rules = updatedRole.Rules
 
 	if err = r.Update(ctx, existsRole); err != nil {
 		logger.Error(err, "Error on Updating Role...")
@@ -94,5 +96,9 @@ func (r *OpenldapClusterReconciler) compareRole(
 	exists *rbacv1.Role,
 	new *rbacv1.Role,
 ) bool {
-	return utils.CompareMap(exists.Labels, new.Labels)
+	if !utils.CompareMap(exists.Labels, new.Labels) {
+		return false
+	}
+
+	return reflect.DeepEqual(exists.Rules, new.Rules)
 }
@@ -0,0 +1,326 @@
+package controller
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	openldapv1 "github.com/qwp0905/openldap-operator/api/v1"
+	"github.com/qwp0905/openldap-operator/pkg/monitors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+type podMonitor struct {
+	role     string
+	snapshot *monitors.MonitorSnapshot
+}
+
+// monitorCollector exposes the last cn=Monitor snapshot of every pod scraped
+// by the operator. Snapshots are keyed by cluster and then by pod name.
+type monitorCollector struct {
+	snapshots sync.Map
+
+	scrapeSuccess      *prometheus.Desc
+	connections        *prometheus.Desc
+	operationsStarted  *prometheus.Desc
+	operationsComplete *prometheus.Desc
+	threads            *prometheus.Desc
+	waiters            *prometheus.Desc
+	entries            *prometheus.Desc
+	contextCsn         *prometheus.Desc
+}
+
+var clusterMonitor = newMonitorCollector()
+
+func init() {
+	metrics.Registry.MustRegister(clusterMonitor)
+}
+
+func newMonitorCollector() *monitorCollector {
+	labels := []string{"namespace", "cluster", "pod", "role"}
+
+	return &monitorCollector{
+		scrapeSuccess: prometheus.NewDesc(
+			"openldap_cluster_scrape_success",
+			"Whether cn=Monitor of the pod was scraped successfully",
+			labels,
+			nil,
+		),
+		connections: prometheus.NewDesc(
+			"openldap_cluster_connections",
+			"Connections of the pod, by type",
+			append(labels, "type"),
+			nil,
+		),
+		operationsStarted: prometheus.NewDesc(
+			"openldap_cluster_operations_initiated_total",
+			"Operations initiated by the pod, by operation",
+			append(labels, "operation"),
+			nil,
+		),
+		operationsComplete: prometheus.NewDesc(
+			"openldap_cluster_operations_completed_total",
+			"Operations completed by the pod, by operation",
+			append(labels, "operation"),
+			nil,
+		),
+		threads: prometheus.NewDesc(
+			"openldap_cluster_threads",
+			"Threads of the pod, by state",
+			append(labels, "state"),
+			nil,
+		),
+		waiters: prometheus.NewDesc(
+			"openldap_cluster_waiters",
+			"Waiters of the pod, by type",
+			append(labels, "type"),
+			nil,
+		),
+		entries: prometheus.NewDesc(
+			"openldap_cluster_entries",
+			"Entries stored in the databases of the pod",
+			labels,
+			nil,
+		),
+		contextCsn: prometheus.NewDesc(
+			"openldap_cluster_context_csn_timestamp_seconds",
+			"Last change time of the suffix seen by the pod, by server id",
+			append(labels, "server_id"),
+			nil,
+		),
+	}
+}
+
+func (c *monitorCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+func (c *monitorCollector) Collect(ch chan<- prometheus.Metric) {
+	c.snapshots.Range(func(key, value any) bool {
+		cluster := key.(types.NamespacedName)
+
+		for pod, monitor := range value.(map[string]podMonitor) {
+			labels := []string{cluster.Namespace, cluster.Name, pod, monitor.role}
+
+			if monitor.snapshot == nil {
+				ch <- prometheus.MustNewConstMetric(c.scrapeSuccess, prometheus.GaugeValue, 0, labels...)
+				continue
+			}
+			ch <- prometheus.MustNewConstMetric(c.scrapeSuccess, prometheus.GaugeValue, 1, labels...)
+
+			snapshot := monitor.snapshot
+			for name, value := range snapshot.Connections {
+				ch <- prometheus.MustNewConstMetric(c.connections, prometheus.GaugeValue, value, append(labels, name)...)
+			}
+
+			for name, count := range snapshot.Operations {
+				ch <- prometheus.MustNewConstMetric(c.operationsStarted, prometheus.CounterValue, count.Initiated, append(labels, name)...)
+				ch <- prometheus.MustNewConstMetric(c.operationsComplete, prometheus.CounterValue, count.Completed, append(labels, name)...)
+			}
+
+			for name, value := range snapshot.Threads {
+				ch <- prometheus.MustNewConstMetric(c.threads, prometheus.GaugeValue, value, append(labels, name)...)
+			}
+
+			for name, value := range snapshot.Waiters {
+				ch <- prometheus.MustNewConstMetric(c.waiters, prometheus.GaugeValue, value, append(labels, name)...)
+			}
+
+			ch <- prometheus.MustNewConstMetric(c.entries, prometheus.GaugeValue, snapshot.Entries, labels...)
+
+			for serverId, changedAt := range snapshot.ContextCsn {
+				ch <- prometheus.MustNewConstMetric(
+					c.contextCsn,
+					prometheus.GaugeValue,
+					float64(changedAt.Unix()),
+					append(labels, serverId)...,
+				)
+			}
+		}
+
+		return true
+	})
+}
+
+func (c *monitorCollector) store(key types.NamespacedName, monitors map[string]podMonitor) {
+	c.snapshots.Store(key, monitors)
+}
+
+// isCaughtUp checks if the pod has seen every change the current master has
+// seen, comparing the contextCSN by server id of their last snapshots. A
+// replica cluster has no master, the pod is compared with the other pods
+// instead. It is false unless both were scraped in the operator monitor mode.
+func isCaughtUp(cluster *openldapv1.OpenldapCluster, podName string) bool {
+	podMonitors, ok := clusterMonitor.load(clusterKey(cluster))
+	if !cluster.NativeMonitorEnabled() || !ok {
+		return false
+	}
+
+	pod, ok := podMonitors[podName]
+	if !ok || pod.snapshot == nil {
+		return false
+	}
+
+	references := []string{cluster.GetCurrentMaster()}
+	if cluster.ReplicaEnabled() {
+		references = []string{}
+		for name, monitor := range podMonitors {
+			if name != podName && monitor.snapshot != nil {
+				references = append(references, name)
+			}
+		}
+	}
+
+	for _, name := range references {
+		reference, ok := podMonitors[name]
+		if !ok || reference.snapshot == nil {
+			return false
+		}
+
+		for serverId, changedAt := range reference.snapshot.ContextCsn {
+			if pod.snapshot.ContextCsn[serverId].Before(changedAt) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func (c *monitorCollector) delete(key types.NamespacedName) {
+	c.snapshots.Delete(key)
+}
+
+func (c *monitorCollector) load(key types.NamespacedName) (map[string]podMonitor, bool) {
+	value, ok := c.snapshots.Load(key)
+	if !ok {
+		return nil, false
+	}
+
+	return value.(map[string]podMonitor), true
+}
+
+// scrapeMonitor queries cn=Monitor of every pod when the operator monitor mode
+// is enabled, it runs in the observer apart from the reconciliation. Failures
+// are only logged and exposed as scrape_success metric.
+func (r *OpenldapClusterReconciler) scrapeMonitor(
+	ctx context.Context,
+	cluster *openldapv1.OpenldapCluster,
+) {
+	logger := log.FromContext(ctx)
+
+	if !cluster.NativeMonitorEnabled() {
+		clusterMonitor.delete(clusterKey(cluster))
+		return
+	}
+
+	password, err := r.getSecretValue(ctx, cluster, cluster.ExporterBindPassword())
+	if err != nil {
+		logger.Error(err, "Error on getting monitor bind password...")
+		return
+	}
+
+	tlsConfig, err := r.getMonitorTlsConfig(ctx, cluster)
+	if err != nil {
+		logger.Error(err, "Error on getting monitor tls config...")
+		return
+	}
+
+	podMonitors := map[string]podMonitor{}
+	for i := 0; i < cluster.GetReplicas(); i++ {
+		pod, err := r.getPod(ctx, cluster, i)
+		if err != nil || pod.Status.PodIP == "" {
+			continue
+		}
+
+		var podTlsConfig *tls.Config
+		if tlsConfig != nil {
+			podTlsConfig = tlsConfig.Clone()
+			podTlsConfig.ServerName = cluster.PodDnsName(i)
+		}
+
+		snapshot, err := monitors.ScrapeMonitor(monitors.ScrapeTarget{
+			Url: fmt.Sprintf(
+				"%s://%s:%s",
+				cluster.ServingScheme(),
+				pod.Status.PodIP,
+				strconv.Itoa(int(cluster.ServingPort())),
+			),
+			TlsConfig:    podTlsConfig,
+			StartTls:     cluster.StartTlsRequired(),
+			BindDn:       cluster.ExporterBindDn(),
+			BindPassword: password,
+			Root:         cluster.Spec.OpenldapConfig.Root,
+		})
+		if err != nil {
+			logger.V(1).Info(fmt.Sprintf("Scraping cn=Monitor of %s failed: %s", pod.Name, err))
+		}
+
+		podMonitors[pod.Name] = podMonitor{
+			role:     pod.Labels["app.kubernetes.io/component"],
+			snapshot: snapshot,
+		}
+	}
+
+	clusterMonitor.store(clusterKey(cluster), podMonitors)
+}
+
+func (r *OpenldapClusterReconciler) getMonitorTlsConfig(
+	ctx context.Context,
+	cluster *openldapv1.OpenldapCluster,
+) (*tls.Config, error) {
+	if !cluster.LdapsOnly() && !cluster.RequireTls() {
+		return nil, nil
+	}
+
+	ca, err := r.getSecretValue(ctx, cluster, &corev1.SecretKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{
+			Name: cluster.Spec.OpenldapConfig.Tls.SecretName,
+		},
+		Key: cluster.Spec.OpenldapConfig.Tls.CaFile,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM([]byte(ca)) {
+		return nil, fmt.Errorf("no certificate found in %s", cluster.Spec.OpenldapConfig.Tls.CaFile)
+	}
+
+	return &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}, nil
+}
+
+func (r *OpenldapClusterReconciler) getSecretValue(
+	ctx context.Context,
+	cluster *openldapv1.OpenldapCluster,
+	selector *corev1.SecretKeySelector,
+) (string, error) {
+	if selector == nil {
+		return "", fmt.Errorf("secret key selector not provided")
+	}
+
+	secret := &corev1.Secret{}
+
+	if err := r.Get(
+		ctx,
+		types.NamespacedName{Name: selector.Name, Namespace: cluster.Namespace},
+		secret,
+	); err != nil {
+		return "", err
+	}
+
+	value, ok := secret.Data[selector.Key]
+	if !ok {
+		return "", fmt.Errorf("key %s not found in secret %s", selector.Key, selector.Name)
+	}
+
+	return string(value), nil
+}
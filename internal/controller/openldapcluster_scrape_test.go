@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	openldapv1 "github.com/qwp0905/openldap-operator/api/v1"
+	"github.com/qwp0905/openldap-operator/internal/fixtures"
+)
+
+// newTestCertificate returns a pem encoded self signed certificate for the
+// dns names.
+func newTestCertificate(dnsNames ...string) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ldap-ca"},
+		DNSNames:              dnsNames,
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	Expect(err).NotTo(HaveOccurred())
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+var _ = Describe("Monitor scrape", func() {
+	ctx := context.Background()
+
+	It("scrapes without tls unless tls is required", func() {
+		reconciler, _ := newTestReconciler()
+		cluster := fixtures.WithTls(fixtures.NewCluster("ldap", 3))
+
+		Expect(reconciler.getMonitorTlsConfig(ctx, cluster)).To(BeNil())
+	})
+
+	It("trusts the cluster ca if tls is required", func() {
+		reconciler, _ := newTestReconciler()
+		cluster := createTestCluster(ctx, 3)
+		cluster = fixtures.WithTls(cluster)
+		cluster.Spec.OpenldapConfig.Tls.RequireTls = true
+
+		_, err := reconciler.getMonitorTlsConfig(ctx, cluster)
+		Expect(err).To(HaveOccurred())
+
+		Expect(k8sClient.Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      cluster.Spec.OpenldapConfig.Tls.SecretName,
+				Namespace: cluster.Namespace,
+			},
+			Data: map[string][]byte{"ca.crt": newTestCertificate()},
+		})).To(Succeed())
+
+		tlsConfig, err := reconciler.getMonitorTlsConfig(ctx, cluster)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tlsConfig.RootCAs).NotTo(BeNil())
+		Expect(cluster.StartTlsRequired()).To(BeTrue())
+	})
+
+	It("compares a pod of a replica cluster with the other pods", func() {
+		cluster := fixtures.WithReplica(fixtures.NewCluster("ldap", 3), "ldaps://ldap.example.com:636")
+		cluster.Spec.Monitor.Enabled = true
+		cluster.Spec.Monitor.Mode = openldapv1.MonitorModeOperator
+		cluster.SetDefault()
+
+		now := time.Now()
+		storeTestSnapshots(cluster, now, now.Add(-time.Second), now)
+		Expect(isCaughtUp(cluster, cluster.PodName(0))).To(BeTrue())
+		Expect(isCaughtUp(cluster, cluster.PodName(1))).To(BeFalse())
+	})
+})
@@ -2,6 +2,7 @@ package controller
 
 import (
 	"context"
+	"reflect"
 
 	openldapv1 "github.com/qwp0905/openldap-operator/api/v1"
 	"github.com/qwp0905/openldap-operator/pkg/services"
@@ -9,6 +10,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
@@ -16,7 +18,15 @@ func (r *OpenldapClusterReconciler) ensureService(
 	ctx context.Context,
 	cluster *openldapv1.OpenldapCluster,
 ) (bool, error) {
-	requeue, err := r.ensureWriteService(ctx, cluster)
+	requeue, err := r.ensureHeadlessService(ctx, cluster)
+	if err != nil {
+		return false, err
+	}
+	if requeue {
+		return true, nil
+	}
+
+	requeue, err = r.ensureWriteService(ctx, cluster)
 	if err != nil {
 		return false, err
 	}
@@ -61,6 +71,68 @@ func (r *OpenldapClusterReconciler) getService(
 	return service, nil
 }
 
+func (r *OpenldapClusterReconciler) ensureHeadlessService(
+	ctx context.Context,
+	cluster *openldapv1.OpenldapCluster,
+) (bool, error) {
+	logger := log.FromContext(ctx)
+	existsService, err := r.getService(ctx, cluster, cluster.HeadlessServiceName())
+
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			logger.Error(err, "Error on getting headless service....")
+			return false, err
+		}
+
+		newService := services.CreateHeadlessService(cluster)
+
+		if err = r.registerObject(cluster, newService); err != nil {
+			logger.Error(err, "Error on Registering Headless Service...")
+			return false, err
+		}
+
+		if err = r.Create(ctx, newService); err != nil {
+			logger.Error(err, "Error on Creating Headless Service...")
+			return false, err
+		}
+
+		r.Recorder.Eventf(
+			cluster,
+			"Normal",
+			"HeadlessServiceCreated",
+			"Service %s created",
+			newService.Name,
+		)
+		logger.Info("Headless Service Created")
+		return true, nil
+	}
+
+	updatedService := services.CreateHeadlessService(cluster)
+
+	if r.compareService(existsService, updatedService) {
+		return false, nil
+	}
+
+	existsService.SetLabels(updatedService.GetLabels())
+	existsService.SetAnnotations(updatedService.GetAnnotations())
+	existsService.Spec = updatedService.Spec
+
+	if err = r.Update(ctx, existsService); err != nil {
+		logger.Error(err, "Error on Updating Headless Service...")
+		return false, err
+	}
+
+	r.Recorder.Eventf(
+		cluster,
+		"Normal",
+		"HeadlessServiceUpdated",
+		"Service %s updated",
+		updatedService.Name,
+	)
+	logger.Info("Headless Service Updated")
+	return true, nil
+}
+
 func (r *OpenldapClusterReconciler) ensureWriteService(
 	ctx context.Context,
 	cluster *openldapv1.OpenldapCluster,
@@ -128,6 +200,12 @@ func (r *OpenldapClusterReconciler) ensureReadService(
 	cluster *openldapv1.OpenldapCluster,
 ) (bool, error) {
 	logger := log.FromContext(ctx)
+	slaveReady, err := r.isSlaveReady(ctx, cluster)
+	if err != nil {
+		logger.Error(err, "Error on listing slave pods....")
+		return false, err
+	}
+
 	existsService, err := r.getService(ctx, cluster, cluster.Name)
 
 	if err != nil {
@@ -136,7 +214,7 @@ func (r *OpenldapClusterReconciler) ensureReadService(
 			return false, err
 		}
 
-		newService := services.CreateReadService(cluster)
+		newService := services.CreateReadService(cluster, slaveReady)
 
 		if err = r.registerObject(cluster, newService); err != nil {
 			logger.Error(err, "Error on Registering Read Service...")
@@ -159,7 +237,7 @@ func (r *OpenldapClusterReconciler) ensureReadService(
 		return true, nil
 	}
 
-	updatedService := services.CreateReadService(cluster)
+	updatedService := services.CreateReadService(cluster, slaveReady)
 
 	if r.compareService(existsService, updatedService) {
 		return false, nil
@@ -185,6 +263,28 @@ func (r *OpenldapClusterReconciler) ensureReadService(
 	return true, nil
 }
 
+func (r *OpenldapClusterReconciler) isSlaveReady(
+	ctx context.Context,
+	cluster *openldapv1.OpenldapCluster,
+) (bool, error) {
+	if !cluster.ReadExcludeMaster() {
+		return false, nil
+	}
+
+	podList := &corev1.PodList{}
+
+	if err := r.List(
+		ctx,
+		podList,
+		client.InNamespace(cluster.Namespace),
+		client.MatchingLabels(cluster.SlaveSelectorLabels()),
+	); err != nil {
+		return false, err
+	}
+
+	return utils.CountReadyPods(utils.FilterActivePods(podList.Items)) > 0, nil
+}
+
 func (r *OpenldapClusterReconciler) ensureMetricsService(
 	ctx context.Context,
 	cluster *openldapv1.OpenldapCluster,
@@ -259,8 +359,43 @@ func (r *OpenldapClusterReconciler) compareService(
 		return false
 	}
 
+	if exists.Spec.Type != new.Spec.Type {
+		return false
+	}
+
+	if !reflect.DeepEqual(exists.Spec.Selector, new.Spec.Selector) {
+		return false
+	}
+
+	if new.Spec.Type != corev1.ServiceTypeClusterIP &&
+		exists.Spec.ExternalTrafficPolicy != new.Spec.ExternalTrafficPolicy {
+		return false
+	}
+
+	if !reflect.DeepEqual(exists.Spec.LoadBalancerClass, new.Spec.LoadBalancerClass) {
+		return false
+	}
+
+	if !reflect.DeepEqual(exists.Spec.LoadBalancerSourceRanges, new.Spec.LoadBalancerSourceRanges) {
+		return false
+	}
+
+	newPorts := new.DeepCopy().Spec.Ports
+	for i, port := range newPorts {
+		if port.NodePort != 0 {
+			continue
+		}
+
+		existsPort := utils.Find(exists.Spec.Ports, func(e corev1.ServicePort) bool {
+			return e.Name == port.Name
+		})
+		if existsPort != nil {
+			newPorts[i].NodePort = existsPort.NodePort
+		}
+	}
+
 	return utils.CompareServicePorts(
 		exists.DeepCopy().Spec.Ports,
-		new.DeepCopy().Spec.Ports,
+		newPorts,
 	)
 }
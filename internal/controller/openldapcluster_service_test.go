@@ -0,0 +1,35 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ = Describe("Read service", func() {
+	ctx := context.Background()
+
+	It("falls back to every pod until a slave is ready", func() {
+		r, _ := newTestReconciler()
+		cluster := createTestCluster(ctx, 2)
+		cluster.Spec.Services.Read.ExcludeMaster = true
+
+		createTestPod(ctx, cluster, 0, cluster.MasterSelectorLabels(), true)
+		slave := createTestPod(ctx, cluster, 1, cluster.SlaveSelectorLabels(), false)
+
+		Expect(r.ensureReadService(ctx, cluster)).To(BeTrue())
+		service := &corev1.Service{}
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(cluster), service)).To(Succeed())
+		Expect(service.Spec.Selector).To(Equal(cluster.SelectorLabels()))
+
+		slave.Status.Conditions[0].Status = corev1.ConditionTrue
+		Expect(k8sClient.Status().Update(ctx, slave)).To(Succeed())
+
+		Expect(r.ensureReadService(ctx, cluster)).To(BeTrue())
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(service), service)).To(Succeed())
+		Expect(service.Spec.Selector).To(Equal(cluster.SlaveSelectorLabels()))
+	})
+})
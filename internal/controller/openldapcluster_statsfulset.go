@@ -4,16 +4,25 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"sync"
 
 	openldapv1 "github.com/qwp0905/openldap-operator/api/v1"
 	"github.com/qwp0905/openldap-operator/pkg/statefulsets"
 	"github.com/qwp0905/openldap-operator/pkg/utils"
 	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
+// scaleDownHeld keeps the replicas each cluster is held from scaling down to,
+// so that the hold is only recorded once it starts.
+var scaleDownHeld sync.Map
+
 func (r *OpenldapClusterReconciler) ensureStatefulset(
 	ctx context.Context,
 	cluster *openldapv1.OpenldapCluster,
@@ -27,6 +36,11 @@ func (r *OpenldapClusterReconciler) ensureStatefulset(
 			return false, err
 		}
 
+		requeue, err := r.ensureRetainedVolumes(ctx, cluster)
+		if err != nil || requeue {
+			return requeue, err
+		}
+
 		newStatefulset := statefulsets.CreateStatefulset(cluster)
 
 		if err = r.registerObject(cluster, newStatefulset); err != nil {
@@ -52,11 +66,47 @@ func (r *OpenldapClusterReconciler) ensureStatefulset(
 
 	updatedStatefulset := statefulsets.CreateStatefulset(cluster)
 
+	if !utils.ComparePVC(
+		existsStatefulset.Spec.VolumeClaimTemplates[0],
+		updatedStatefulset.Spec.VolumeClaimTemplates[0],
+	) || isStorageClassChanged(existsStatefulset, cluster) {
+		requeue, err := r.ensureVolumeClaimTemplate(ctx, cluster, existsStatefulset, updatedStatefulset)
+		if err != nil || requeue {
+			return requeue, err
+		}
+	} else if err = r.clearTemplateImmutableCondition(ctx, cluster); err != nil {
+		return false, err
+	}
+
+	replicas := *existsStatefulset.Spec.Replicas
+	if *updatedStatefulset.Spec.Replicas < replicas && holdScaleDown(cluster) {
+		updatedStatefulset.Spec.Replicas = existsStatefulset.Spec.Replicas
+
+		if held, ok := scaleDownHeld.Load(clusterKey(cluster)); !ok || held != cluster.Spec.Replicas {
+			scaleDownHeld.Store(clusterKey(cluster), cluster.Spec.Replicas)
+			r.Recorder.Eventf(
+				cluster,
+				"Normal",
+				"ScaleDownHeld",
+				"Scaling statefulset %s down to %d held until master %s is switched over",
+				existsStatefulset.Name,
+				cluster.Spec.Replicas,
+				cluster.GetCurrentMaster(),
+			)
+		}
+	} else {
+		scaleDownHeld.Delete(clusterKey(cluster))
+	}
+
 	reason, result := compareStatefulset(existsStatefulset, updatedStatefulset)
 	if result {
 		return false, nil
 	}
 
+	if existsStatefulset.Spec.ServiceName != updatedStatefulset.Spec.ServiceName {
+		return r.recreateStatefulset(ctx, cluster, existsStatefulset, "serviceName")
+	}
+
 	existsStatefulset.SetLabels(updatedStatefulset.GetLabels())
 	existsStatefulset.SetAnnotations(updatedStatefulset.GetAnnotations())
 	existsStatefulset.Spec = updatedStatefulset.Spec
@@ -74,6 +124,55 @@ func (r *OpenldapClusterReconciler) ensureStatefulset(
 		updatedStatefulset.Name,
 	)
 	logger.Info(fmt.Sprintf("Statefulset Updated %s changed", reason))
+
+	if replicas > 1 && *existsStatefulset.Spec.Replicas == 1 {
+		r.Recorder.Eventf(
+			cluster,
+			"Warning",
+			"ScaledToOne",
+			"Statefulset %s scaled down to a single replica, the cluster cannot fail over",
+			existsStatefulset.Name,
+		)
+	}
+	return true, nil
+}
+
+// holdScaleDown checks if the replicas of the statefulset must be kept while
+// the master is one of the removed ordinals or an election is in progress, the
+// master is switched over to a surviving ordinal first.
+func holdScaleDown(cluster *openldapv1.OpenldapCluster) bool {
+	return !cluster.ReplicaEnabled() && (cluster.IsMasterScaledDown() || !cluster.IsMasterSame())
+}
+
+// recreateStatefulset deletes the statefulset with orphan propagation so that
+// immutable fields can be changed without taking the pods down. The pods are
+// adopted again by the statefulset created on the next reconcile.
+func (r *OpenldapClusterReconciler) recreateStatefulset(
+	ctx context.Context,
+	cluster *openldapv1.OpenldapCluster,
+	statefulset *appsv1.StatefulSet,
+	reason string,
+) (bool, error) {
+	logger := log.FromContext(ctx)
+
+	if err := r.Delete(
+		ctx,
+		statefulset,
+		client.PropagationPolicy(metav1.DeletePropagationOrphan),
+	); err != nil {
+		logger.Error(err, "Error on Deleting Statefulset for recreation...")
+		return false, err
+	}
+
+	r.Recorder.Eventf(
+		cluster,
+		"Normal",
+		"StatefulsetRecreating",
+		"Statefulset %s deleted with orphan pods because %s changed",
+		statefulset.Name,
+		reason,
+	)
+	logger.Info(fmt.Sprintf("Statefulset Deleted for recreation %s changed", reason))
 	return true, nil
 }
 
@@ -111,6 +210,10 @@ func compareStatefulset(exists *appsv1.StatefulSet, new *appsv1.StatefulSet) (st
 		return "replicas", false
 	}
 
+	if exists.Spec.ServiceName != new.Spec.ServiceName {
+		return "serviceName", false
+	}
+
 	if !utils.CompareEnv(*exCon, *neCon) {
 		return "env", false
 	}
@@ -127,6 +230,47 @@ func compareStatefulset(exists *appsv1.StatefulSet, new *appsv1.StatefulSet) (st
 		return "imagePullPolicy", false
 	}
 
+	if !reflect.DeepEqual(exCon.Ports, neCon.Ports) {
+		return "ports", false
+	}
+
+	exInit := statefulsets.GetInitContainer(exists, new.Spec.Template.Spec.InitContainers[0].Name)
+	if exInit == nil ||
+		!reflect.DeepEqual(exInit.Command, new.Spec.Template.Spec.InitContainers[0].Command) {
+		return "initContainers", false
+	}
+
+	if !reflect.DeepEqual(
+		exists.Spec.Template.Spec.TopologySpreadConstraints,
+		new.Spec.Template.Spec.TopologySpreadConstraints,
+	) {
+		return "topologySpreadConstraints", false
+	}
+
+	if exists.Spec.Template.Spec.ServiceAccountName != new.Spec.Template.Spec.ServiceAccountName &&
+		new.Spec.Template.Spec.ServiceAccountName != "" {
+		return "serviceAccountName", false
+	}
+
+	if len(exists.Spec.Template.Spec.Containers) != len(new.Spec.Template.Spec.Containers) {
+		return "containers", false
+	}
+
+	for _, c := range new.Spec.Template.Spec.Containers {
+		if c.Name == new.Name {
+			continue
+		}
+
+		exSidecar := statefulsets.GetContainer(exists, c.Name)
+		if exSidecar == nil {
+			return "containers", false
+		}
+
+		if !compareSidecar(*exSidecar, c) {
+			return c.Name, false
+		}
+	}
+
 	if !utils.ComparePVC(
 		exists.Spec.VolumeClaimTemplates[0],
 		new.Spec.VolumeClaimTemplates[0],
@@ -134,5 +278,43 @@ func compareStatefulset(exists *appsv1.StatefulSet, new *appsv1.StatefulSet) (st
 		return "pvc", false
 	}
 
+	// the api server drops the policy without the StatefulSetAutoDeletePVC
+	// feature gate
+	if exists.Spec.PersistentVolumeClaimRetentionPolicy != nil && !reflect.DeepEqual(
+		exists.Spec.PersistentVolumeClaimRetentionPolicy,
+		new.Spec.PersistentVolumeClaimRetentionPolicy,
+	) {
+		return "persistentVolumeClaimRetentionPolicy", false
+	}
+
 	return "", true
 }
+
+func compareSidecar(exists corev1.Container, new corev1.Container) bool {
+	if exists.Image != new.Image {
+		return false
+	}
+
+	if new.ImagePullPolicy != "" && exists.ImagePullPolicy != new.ImagePullPolicy {
+		return false
+	}
+
+	if !reflect.DeepEqual(exists.Args, new.Args) {
+		return false
+	}
+
+	if !utils.CompareEnv(exists, new) {
+		return false
+	}
+
+	if !equality.Semantic.DeepEqual(exists.Resources, new.Resources) {
+		return false
+	}
+
+	if new.SecurityContext != nil &&
+		!equality.Semantic.DeepEqual(exists.SecurityContext, new.SecurityContext) {
+		return false
+	}
+
+	return reflect.DeepEqual(exists.Ports, new.Ports)
+}
@@ -0,0 +1,83 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/qwp0905/openldap-operator/pkg/statefulsets"
+)
+
+var _ = Describe("Statefulset", func() {
+	ctx := context.Background()
+
+	It("is governed by the headless service", func() {
+		r, recorder := newTestReconciler()
+		cluster := createTestCluster(ctx, 3)
+
+		Expect(r.ensureStatefulset(ctx, cluster)).To(BeTrue())
+		Expect(recordedReasons(recorder)).To(ContainElement("StatefulsetCreated"))
+
+		statefulset := &appsv1.StatefulSet{}
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(cluster), statefulset)).To(Succeed())
+		Expect(statefulset.Spec.ServiceName).To(Equal(cluster.HeadlessServiceName()))
+	})
+
+	It("is recreated with orphan pods if the governing service changed", func() {
+		r, recorder := newTestReconciler()
+		cluster := createTestCluster(ctx, 3)
+
+		statefulset := statefulsets.CreateStatefulset(cluster.DeepCopy())
+		statefulset.Spec.ServiceName = ""
+		Expect(r.registerObject(cluster, statefulset)).To(Succeed())
+		Expect(k8sClient.Create(ctx, statefulset)).To(Succeed())
+
+		Expect(r.ensureStatefulset(ctx, cluster)).To(BeTrue())
+		Expect(recordedReasons(recorder)).To(ContainElement("StatefulsetRecreating"))
+
+		err := k8sClient.Get(ctx, client.ObjectKeyFromObject(statefulset), statefulset)
+		if !errors.IsNotFound(err) {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(statefulset.DeletionTimestamp).NotTo(BeNil())
+			Expect(statefulset.Finalizers).To(ContainElement("orphan"))
+		}
+	})
+
+	It("holds scaling down until the master is switched over", func() {
+		r, recorder := newTestReconciler()
+		cluster := createTestCluster(ctx, 3)
+		cluster.SetDefault()
+		DeferCleanup(func() { scaleDownHeld.Delete(clusterKey(cluster)) })
+
+		cluster.Spec.Template.ImagePullPolicy = corev1.PullIfNotPresent
+
+		Expect(r.ensureStatefulset(ctx, cluster)).To(BeTrue())
+		recordedReasons(recorder)
+
+		cluster.UpdateDesiredMaster(2)
+		cluster.UpdateCurrentMaster()
+		cluster.Spec.Replicas = 2
+		for i := 0; i < 2; i++ {
+			Expect(r.ensureStatefulset(ctx, cluster)).To(BeFalse())
+		}
+		Expect(recordedReasons(recorder)).To(Equal([]string{"ScaleDownHeld"}))
+
+		statefulset := &appsv1.StatefulSet{}
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(cluster), statefulset)).To(Succeed())
+		Expect(*statefulset.Spec.Replicas).To(Equal(int32(3)))
+
+		cluster.UpdateDesiredMaster(0)
+		cluster.UpdateCurrentMaster()
+		cluster.Spec.Replicas = 1
+		Expect(r.ensureStatefulset(ctx, cluster)).To(BeTrue())
+		Expect(recordedReasons(recorder)).To(Equal([]string{"StatefulsetUpdated", "ScaledToOne"}))
+
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(cluster), statefulset)).To(Succeed())
+		Expect(*statefulset.Spec.Replicas).To(Equal(int32(1)))
+	})
+})
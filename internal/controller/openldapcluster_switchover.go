@@ -0,0 +1,232 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	openldapv1 "github.com/qwp0905/openldap-operator/api/v1"
+	"github.com/qwp0905/openldap-operator/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// switchBackDelay is how long the preferred master must be ready before the
+// master is switched back, so that a flapping pod is not elected right away.
+const switchBackDelay = time.Minute
+
+// switchoverOnDrain moves the master away from a cordoned node before the
+// node is drained, so that the eviction does not take the master down.
+func (r *OpenldapClusterReconciler) switchoverOnDrain(
+	ctx context.Context,
+	cluster *openldapv1.OpenldapCluster,
+	masterPod *corev1.Pod,
+) (bool, error) {
+	logger := log.FromContext(ctx)
+
+	if !cluster.SwitchoverOnDrain() || cluster.GetReplicas() == 1 {
+		return false, nil
+	}
+
+	cordoned, err := r.isNodeCordoned(ctx, masterPod.Spec.NodeName)
+	if err != nil {
+		logger.Error(err, "Error on getting node of master...")
+		return false, err
+	}
+	if !cordoned {
+		return false, nil
+	}
+
+	nextIndex, err := r.getAlivePodIndex(ctx, cluster, func(pod *corev1.Pod) (bool, error) {
+		if pod.Name == masterPod.Name {
+			return true, nil
+		}
+
+		return r.isNodeCordoned(ctx, pod.Spec.NodeName)
+	})
+	if err != nil {
+		logger.Info("Cannot find pod to switchover on schedulable node")
+		return false, nil
+	}
+
+	return true, r.switchMaster(
+		ctx,
+		cluster,
+		masterPod,
+		nextIndex,
+		failoverReasonNodeDrain,
+		openldapv1.ReasonMasterNodeCordoned,
+		fmt.Sprintf("Node %s of master %s is cordoned", masterPod.Spec.NodeName, masterPod.Name),
+	)
+}
+
+// switchoverOnScaleDown moves the master to a surviving ordinal before the
+// statefulset is scaled down, the statefulset keeps its replicas meanwhile.
+func (r *OpenldapClusterReconciler) switchoverOnScaleDown(
+	ctx context.Context,
+	cluster *openldapv1.OpenldapCluster,
+	masterPod *corev1.Pod,
+) (bool, error) {
+	logger := log.FromContext(ctx)
+
+	if index := cluster.PodIndex(masterPod.Name); index < cluster.GetReplicas() {
+		return false, nil
+	}
+
+	nextIndex, err := r.getAlivePodIndex(ctx, cluster, nil)
+	if err != nil {
+		logger.Info("Cannot find surviving pod to switchover before scale down")
+		return true, nil
+	}
+
+	return true, r.switchMaster(
+		ctx,
+		cluster,
+		masterPod,
+		nextIndex,
+		failoverReasonScaleDown,
+		openldapv1.ReasonMasterScaledDown,
+		fmt.Sprintf("Master %s is removed by scaling down to %d", masterPod.Name, cluster.GetReplicas()),
+	)
+}
+
+// switchBackMaster moves the master to the preferred master once it has been
+// ready for a while and caught up with the current master, or away from a pod
+// which is not allowed to be master.
+func (r *OpenldapClusterReconciler) switchBackMaster(
+	ctx context.Context,
+	cluster *openldapv1.OpenldapCluster,
+	masterPod *corev1.Pod,
+) (bool, error) {
+	logger := log.FromContext(ctx)
+
+	if cluster.GetReplicas() == 1 {
+		return false, nil
+	}
+
+	if !cluster.IsElectable(cluster.PodIndex(masterPod.Name)) {
+		nextIndex, err := r.getAlivePodIndex(ctx, cluster, nil)
+		if err != nil {
+			logger.Info("Cannot find pod to switchover from excluded master")
+			return false, nil
+		}
+
+		return true, r.switchMaster(
+			ctx,
+			cluster,
+			masterPod,
+			nextIndex,
+			failoverReasonNotElectable,
+			openldapv1.ReasonMasterNotElectable,
+			fmt.Sprintf("Master %s is one of excluded ordinals", masterPod.Name),
+		)
+	}
+
+	preferred := cluster.PreferredMasterIndex()
+	if preferred < 0 ||
+		preferred >= cluster.GetReplicas() ||
+		cluster.PodName(preferred) == masterPod.Name {
+		return false, nil
+	}
+
+	pod, err := r.getPod(ctx, cluster, preferred)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			logger.Error(err, "Error on getting preferred master pod...")
+			return false, err
+		}
+
+		return false, nil
+	}
+
+	if !utils.IsPodAlive(*pod) || !utils.IsPodReadyFor(*pod, switchBackDelay) {
+		return false, nil
+	}
+
+	cordoned, err := r.isNodeCordoned(ctx, pod.Spec.NodeName)
+	if err != nil {
+		logger.Error(err, "Error on getting node of preferred master...")
+		return false, err
+	}
+	if cordoned {
+		return false, nil
+	}
+
+	if !isCaughtUp(cluster, pod.Name) {
+		logger.V(1).Info(fmt.Sprintf("Waiting for preferred master %s to catch up", pod.Name))
+		return false, nil
+	}
+
+	return true, r.switchMaster(
+		ctx,
+		cluster,
+		masterPod,
+		preferred,
+		failoverReasonPreferredMaster,
+		openldapv1.ReasonPreferredMaster,
+		fmt.Sprintf("Preferred master %s is ready", pod.Name),
+	)
+}
+
+// switchMaster elects the pod of the index as the next master and deletes
+// the current master pod, so that it is restarted as a slave.
+func (r *OpenldapClusterReconciler) switchMaster(
+	ctx context.Context,
+	cluster *openldapv1.OpenldapCluster,
+	masterPod *corev1.Pod,
+	nextIndex int,
+	failoverReason string,
+	reason string,
+	message string,
+) error {
+	logger := log.FromContext(ctx)
+
+	r.Recorder.Eventf(
+		cluster,
+		"Normal",
+		"MasterSwitchover",
+		"%s, switching master to %s",
+		message,
+		cluster.PodName(nextIndex),
+	)
+	observeFailover(cluster, failoverReason)
+
+	if err := r.fenceMaster(ctx, cluster); err != nil {
+		return err
+	}
+
+	cluster.SetConditionElected(false, reason, message)
+	cluster.UpdateDesiredMaster(nextIndex)
+	if err := r.Status().Update(ctx, cluster); err != nil {
+		logger.Error(err, "Error on Updating Cluster Desired Master....")
+		return err
+	}
+
+	if err := r.Delete(ctx, masterPod); err != nil {
+		logger.Error(err, "Error on deleting switched master pod...")
+		return err
+	}
+
+	logger.Info(fmt.Sprintf("Desired master switched to %s", strconv.Itoa(nextIndex)))
+	return nil
+}
+
+func (r *OpenldapClusterReconciler) isNodeCordoned(ctx context.Context, nodeName string) (bool, error) {
+	if nodeName == "" {
+		return false, nil
+	}
+
+	node := &corev1.Node{}
+	if err := r.Get(ctx, types.NamespacedName{Name: nodeName}, node); err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return node.Spec.Unschedulable, nil
+}
@@ -0,0 +1,78 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	openldapv1 "github.com/qwp0905/openldap-operator/api/v1"
+)
+
+var _ = Describe("Switch back", func() {
+	ctx := context.Background()
+
+	// createSwitchBackCluster returns a cluster with ldap-0 elected, ldap-1 as
+	// preferred master and every pod ready for an hour.
+	createSwitchBackCluster := func() *openldapv1.OpenldapCluster {
+		cluster := createTestCluster(ctx, 3)
+		cluster.UpdateDesiredMaster(0)
+		cluster.UpdateCurrentMaster()
+		Expect(k8sClient.Status().Update(ctx, cluster)).To(Succeed())
+
+		cluster.Spec.Monitor.Enabled = true
+		cluster.Spec.Monitor.Mode = openldapv1.MonitorModeOperator
+		preferred := int32(1)
+		cluster.Spec.Election.PreferredMaster = &preferred
+		cluster.SetDefault()
+
+		for i := 0; i < cluster.GetReplicas(); i++ {
+			createTestPod(ctx, cluster, i, cluster.SelectorLabels(), true)
+		}
+
+		return cluster
+	}
+
+	It("waits for the preferred master to catch up", func() {
+		reconciler, _ := newTestReconciler()
+		cluster := createSwitchBackCluster()
+		masterPod, err := reconciler.getPod(ctx, cluster, 0)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(reconciler.switchBackMaster(ctx, cluster, masterPod)).To(BeFalse())
+
+		now := time.Now()
+		storeTestSnapshots(cluster, now, now.Add(-time.Second), now)
+		Expect(reconciler.switchBackMaster(ctx, cluster, masterPod)).To(BeFalse())
+	})
+
+	It("switches back once the preferred master caught up", func() {
+		reconciler, recorder := newTestReconciler()
+		cluster := createSwitchBackCluster()
+		masterPod, err := reconciler.getPod(ctx, cluster, 0)
+		Expect(err).NotTo(HaveOccurred())
+
+		now := time.Now()
+		storeTestSnapshots(cluster, now, now, now.Add(-time.Second))
+		Expect(reconciler.switchBackMaster(ctx, cluster, masterPod)).To(BeTrue())
+		Expect(recordedReasons(recorder)).To(ContainElement("MasterSwitchover"))
+
+		updated := getTestCluster(ctx, cluster)
+		Expect(updated.GetDesiredMaster()).To(Equal(cluster.PodName(1)))
+		Expect(updated.GetCondition(openldapv1.ConditionElected).Reason).
+			To(Equal(openldapv1.ReasonPreferredMaster))
+	})
+
+	It("switches away from an excluded master without waiting", func() {
+		reconciler, _ := newTestReconciler()
+		cluster := createSwitchBackCluster()
+		cluster.Spec.Election.PreferredMaster = nil
+		cluster.Spec.Election.ExcludedOrdinals = []int32{0}
+		masterPod, err := reconciler.getPod(ctx, cluster, 0)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(reconciler.switchBackMaster(ctx, cluster, masterPod)).To(BeTrue())
+		Expect(getTestCluster(ctx, cluster).GetDesiredMaster()).To(Equal(cluster.PodName(1)))
+	})
+})
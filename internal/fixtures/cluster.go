@@ -0,0 +1,85 @@
+package fixtures
+
+import (
+	openldapv1 "github.com/qwp0905/openldap-operator/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NewCluster returns a defaulted cluster accepted by the webhook, shared by
+// the test suites of every package.
+func NewCluster(name string, replicas int32) *openldapv1.OpenldapCluster {
+	cluster := &openldapv1.OpenldapCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+		},
+		Spec: openldapv1.OpenldapClusterSpec{
+			Template: openldapv1.ClusterPodTemplate{
+				Image: "qwp1216/openldap:2.6.4",
+			},
+			Replicas: replicas,
+			Storage: &openldapv1.StorageConfig{
+				VolumeClaimTemplate: corev1.PersistentVolumeClaimSpec{
+					AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceStorage: resource.MustParse("8Gi"),
+						},
+					},
+				},
+			},
+			OpenldapConfig: &openldapv1.OpenldapConfig{
+				Root: "dc=example,dc=com",
+				AdminPassword: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: name},
+					Key:                  "admin",
+				},
+				ConfigPassword: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: name},
+					Key:                  "config",
+				},
+			},
+		},
+	}
+	cluster.SetDefault()
+
+	return cluster
+}
+
+// WithTls enables tls from the secret of the same name as the cluster.
+func WithTls(cluster *openldapv1.OpenldapCluster) *openldapv1.OpenldapCluster {
+	cluster.Spec.OpenldapConfig.Tls.Enabled = true
+	cluster.Spec.OpenldapConfig.Tls.SecretName = cluster.Name + "-tls"
+	cluster.SetDefault()
+
+	return cluster
+}
+
+// WithTopology runs the cluster in the topology mode.
+func WithTopology(cluster *openldapv1.OpenldapCluster, mode openldapv1.TopologyMode) *openldapv1.OpenldapCluster {
+	cluster.Spec.Topology.Mode = mode
+	cluster.SetDefault()
+
+	return cluster
+}
+
+// WithReplica runs the cluster as replica of the source, bound with the
+// password in the secret of the same name as the cluster.
+func WithReplica(cluster *openldapv1.OpenldapCluster, url string) *openldapv1.OpenldapCluster {
+	cluster.Spec.Replica = &openldapv1.ReplicaConfig{
+		Enabled: true,
+		Source: &openldapv1.ReplicaSource{
+			Url:    url,
+			BindDn: "cn=replicator,dc=example,dc=com",
+			BindPassword: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: cluster.Name},
+				Key:                  "replica",
+			},
+		},
+	}
+	cluster.SetDefault()
+
+	return cluster
+}
@@ -0,0 +1,63 @@
+package autoscalers
+
+import (
+	openldapv1 "github.com/qwp0905/openldap-operator/api/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func CreateHorizontalPodAutoscaler(cluster *openldapv1.OpenldapCluster) *autoscalingv2.HorizontalPodAutoscaler {
+	autoscaling := cluster.GetAutoscaling()
+	minReplicas := cluster.AutoscalingMinReplicas()
+	metrics := []autoscalingv2.MetricSpec{}
+
+	if autoscaling.TargetCPUUtilizationPercentage != nil {
+		metrics = append(metrics, autoscalingv2.MetricSpec{
+			Type: autoscalingv2.ResourceMetricSourceType,
+			Resource: &autoscalingv2.ResourceMetricSource{
+				Name: corev1.ResourceCPU,
+				Target: autoscalingv2.MetricTarget{
+					Type:               autoscalingv2.UtilizationMetricType,
+					AverageUtilization: autoscaling.TargetCPUUtilizationPercentage,
+				},
+			},
+		})
+	}
+
+	if autoscaling.SearchRate != nil {
+		averageValue := autoscaling.SearchRate.AverageValue.DeepCopy()
+		metrics = append(metrics, autoscalingv2.MetricSpec{
+			Type: autoscalingv2.PodsMetricSourceType,
+			Pods: &autoscalingv2.PodsMetricSource{
+				Metric: autoscalingv2.MetricIdentifier{
+					Name: autoscaling.SearchRate.Metric,
+				},
+				Target: autoscalingv2.MetricTarget{
+					Type:         autoscalingv2.AverageValueMetricType,
+					AverageValue: &averageValue,
+				},
+			},
+		})
+	}
+
+	return &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        cluster.Name,
+			Namespace:   cluster.Namespace,
+			Labels:      cluster.DefaultLabels(),
+			Annotations: cluster.GetAnnotations(),
+		},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{
+				APIVersion: openldapv1.GroupVersion.String(),
+				Kind:       "OpenldapCluster",
+				Name:       cluster.Name,
+			},
+			MinReplicas: &minReplicas,
+			MaxReplicas: autoscaling.MaxReplicas,
+			Metrics:     metrics,
+			Behavior:    autoscaling.Behavior,
+		},
+	}
+}
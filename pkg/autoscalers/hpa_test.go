@@ -0,0 +1,45 @@
+package autoscalers
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	openldapv1 "github.com/qwp0905/openldap-operator/api/v1"
+	"github.com/qwp0905/openldap-operator/internal/fixtures"
+)
+
+var _ = Describe("HorizontalPodAutoscaler", func() {
+	It("scales the cluster through its scale subresource", func() {
+		cluster := fixtures.NewCluster("ldap", 3)
+		cluster.Spec.Autoscaling.Enabled = true
+		cluster.Spec.Autoscaling.MaxReplicas = 5
+		cpu := int32(70)
+		cluster.Spec.Autoscaling.TargetCPUUtilizationPercentage = &cpu
+
+		hpa := CreateHorizontalPodAutoscaler(cluster)
+		Expect(hpa.Spec.ScaleTargetRef.APIVersion).To(Equal(openldapv1.GroupVersion.String()))
+		Expect(hpa.Spec.ScaleTargetRef.Kind).To(Equal("OpenldapCluster"))
+		Expect(hpa.Spec.ScaleTargetRef.Name).To(Equal("ldap"))
+		Expect(*hpa.Spec.MinReplicas).To(Equal(int32(2)))
+		Expect(hpa.Spec.MaxReplicas).To(Equal(int32(5)))
+		Expect(hpa.Spec.Metrics).To(HaveLen(1))
+		Expect(hpa.Spec.Metrics[0].Resource.Target.AverageUtilization).To(Equal(&cpu))
+	})
+
+	It("scales on the search rate of the exporter", func() {
+		cluster := fixtures.NewCluster("ldap", 3)
+		cluster.Spec.Autoscaling.Enabled = true
+		cluster.Spec.Autoscaling.SearchRate = &openldapv1.SearchRateTarget{
+			AverageValue: resource.MustParse("500"),
+		}
+		cluster.SetDefault()
+
+		hpa := CreateHorizontalPodAutoscaler(cluster)
+		Expect(hpa.Spec.Metrics).To(HaveLen(1))
+		Expect(hpa.Spec.Metrics[0].Type).To(Equal(autoscalingv2.PodsMetricSourceType))
+		Expect(hpa.Spec.Metrics[0].Pods.Metric.Name).To(Equal("openldap_search_operations_per_second"))
+		Expect(hpa.Spec.Metrics[0].Pods.Target.AverageValue.String()).To(Equal("500"))
+	})
+})
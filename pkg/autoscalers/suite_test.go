@@ -0,0 +1,14 @@
+package autoscalers
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestAutoscalers(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	RunSpecs(t, "Autoscalers Suite")
+}
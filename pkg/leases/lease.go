@@ -0,0 +1,50 @@
+package leases
+
+import (
+	"time"
+
+	openldapv1 "github.com/qwp0905/openldap-operator/api/v1"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func CreateMasterLease(cluster *openldapv1.OpenldapCluster, holder string) *coordinationv1.Lease {
+	duration := cluster.GetFencing().LeaseDurationSeconds
+	now := metav1.NowMicro()
+
+	return &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        cluster.LeaseName(),
+			Namespace:   cluster.Namespace,
+			Labels:      cluster.DefaultLabels(),
+			Annotations: cluster.GetAnnotations(),
+		},
+		Spec: coordinationv1.LeaseSpec{
+			HolderIdentity:       &holder,
+			LeaseDurationSeconds: &duration,
+			AcquireTime:          &now,
+			RenewTime:            &now,
+		},
+	}
+}
+
+// IsLeaseExpired checks if the lease has not been renewed within its duration.
+func IsLeaseExpired(lease *coordinationv1.Lease) bool {
+	if lease.Spec.RenewTime == nil || lease.Spec.LeaseDurationSeconds == nil {
+		return true
+	}
+
+	expiry := lease.Spec.RenewTime.Add(
+		time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second,
+	)
+
+	return time.Now().After(expiry)
+}
+
+func GetHolder(lease *coordinationv1.Lease) string {
+	if lease.Spec.HolderIdentity == nil {
+		return ""
+	}
+
+	return *lease.Spec.HolderIdentity
+}
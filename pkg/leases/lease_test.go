@@ -0,0 +1,34 @@
+package leases
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/qwp0905/openldap-operator/internal/fixtures"
+)
+
+var _ = Describe("Master lease", func() {
+	It("is held by the master for the lease duration", func() {
+		cluster := fixtures.NewCluster("ldap", 3)
+		lease := CreateMasterLease(cluster, "ldap-0")
+
+		Expect(lease.Name).To(Equal(cluster.LeaseName()))
+		Expect(GetHolder(lease)).To(Equal("ldap-0"))
+		Expect(*lease.Spec.LeaseDurationSeconds).To(Equal(int32(30)))
+		Expect(IsLeaseExpired(lease)).To(BeFalse())
+	})
+
+	It("expires once not renewed within its duration", func() {
+		lease := CreateMasterLease(fixtures.NewCluster("ldap", 3), "ldap-0")
+		renewed := metav1.NewMicroTime(time.Now().Add(-31 * time.Second))
+		lease.Spec.RenewTime = &renewed
+
+		Expect(IsLeaseExpired(lease)).To(BeTrue())
+
+		lease.Spec.RenewTime = nil
+		Expect(IsLeaseExpired(lease)).To(BeTrue())
+	})
+})
@@ -0,0 +1,14 @@
+package leases
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestLeases(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	RunSpecs(t, "Leases Suite")
+}
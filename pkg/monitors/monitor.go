@@ -31,15 +31,17 @@ func CreateServiceMonitor(cluster *openldapv1.OpenldapCluster) *monitoringv1.Ser
 			},
 			Endpoints: []monitoringv1.Endpoint{
 				{
-					Port:            cluster.MetricsPortName(),
-					Path:            cluster.MetricsPath(),
-					Interval:        monitoringv1.Duration(cluster.Spec.Monitor.Interval),
-					ScrapeTimeout:   monitoringv1.Duration(cluster.Spec.Monitor.ScrapeTimeout),
-					HonorTimestamps: &trueValue,
-					HonorLabels:     true,
-					EnableHttp2:     &falseValue,
-					FilterRunning:   &trueValue,
-					FollowRedirects: &falseValue,
+					Port:                 cluster.MetricsPortName(),
+					Path:                 cluster.MetricsPath(),
+					Interval:             monitoringv1.Duration(cluster.Spec.Monitor.Interval),
+					ScrapeTimeout:        monitoringv1.Duration(cluster.Spec.Monitor.ScrapeTimeout),
+					HonorTimestamps:      &trueValue,
+					HonorLabels:          true,
+					EnableHttp2:          &falseValue,
+					FilterRunning:        &trueValue,
+					FollowRedirects:      &falseValue,
+					RelabelConfigs:       cluster.Spec.Monitor.Relabelings,
+					MetricRelabelConfigs: cluster.Spec.Monitor.MetricRelabelings,
 				},
 			},
 		},
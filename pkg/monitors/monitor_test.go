@@ -0,0 +1,28 @@
+package monitors
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+
+	"github.com/qwp0905/openldap-operator/internal/fixtures"
+)
+
+var _ = Describe("ServiceMonitor", func() {
+	It("passes the relabelings to the endpoint", func() {
+		cluster := fixtures.NewCluster("ldap", 3)
+		cluster.Spec.Monitor.Enabled = true
+		cluster.Spec.Monitor.Relabelings = []*monitoringv1.RelabelConfig{
+			{TargetLabel: "team", Replacement: "directory"},
+		}
+		cluster.Spec.Monitor.MetricRelabelings = []*monitoringv1.RelabelConfig{
+			{SourceLabels: []monitoringv1.LabelName{"__name__"}, Regex: "go_.*", Action: "drop"},
+		}
+		cluster.SetDefault()
+
+		endpoint := CreateServiceMonitor(cluster).Spec.Endpoints[0]
+		Expect(endpoint.Port).To(Equal(cluster.MetricsPortName()))
+		Expect(endpoint.RelabelConfigs).To(Equal(cluster.Spec.Monitor.Relabelings))
+		Expect(endpoint.MetricRelabelConfigs).To(Equal(cluster.Spec.Monitor.MetricRelabelings))
+	})
+})
@@ -0,0 +1,174 @@
+package monitors
+
+import (
+	"fmt"
+
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	openldapv1 "github.com/qwp0905/openldap-operator/api/v1"
+	"github.com/qwp0905/openldap-operator/pkg/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func CreatePrometheusRule(cluster *openldapv1.OpenldapCluster) *monitoringv1.PrometheusRule {
+	config := cluster.Spec.Monitor.Rules
+	selector := fmt.Sprintf(`namespace="%s",job="%s"`, cluster.Namespace, cluster.Name)
+	alertLabels := utils.MergeMap(
+		map[string]string{"severity": "warning"},
+		config.Labels,
+	)
+	criticalLabels := utils.MergeMap(
+		map[string]string{"severity": "critical"},
+		config.Labels,
+	)
+
+	downExpr := fmt.Sprintf(`up{%s} == 0`, selector)
+	lagExpr := fmt.Sprintf(
+		`max by (id) (openldap_monitor_replication{%s,type="gt"}) - min by (id) (openldap_monitor_replication{%s,type="gt"}) > %d`,
+		selector,
+		selector,
+		config.ReplicationLagSeconds,
+	)
+	conflictLabel := "id"
+	conflictExpr := fmt.Sprintf(
+		`count by (id) (count_values by (id) ("csn", openldap_monitor_replication{%s,type="gt"})) > 1`,
+		selector,
+	)
+	connectionsExpr := fmt.Sprintf(
+		`openldap_monitor_counter_object{%s,dn="cn=Current,cn=Connections,cn=Monitor"} > %d`,
+		selector,
+		config.MaxConnections,
+	)
+
+	if cluster.NativeMonitorEnabled() {
+		selector = fmt.Sprintf(`namespace="%s",cluster="%s"`, cluster.Namespace, cluster.Name)
+		downExpr = fmt.Sprintf(`openldap_cluster_scrape_success{%s} == 0`, selector)
+		lagExpr = fmt.Sprintf(
+			`max by (server_id) (openldap_cluster_context_csn_timestamp_seconds{%s}) - min by (server_id) (openldap_cluster_context_csn_timestamp_seconds{%s}) > %d`,
+			selector,
+			selector,
+			config.ReplicationLagSeconds,
+		)
+		conflictLabel = "server_id"
+		conflictExpr = fmt.Sprintf(
+			`count by (server_id) (count_values by (server_id) ("csn", openldap_cluster_context_csn_timestamp_seconds{%s})) > 1`,
+			selector,
+		)
+		connectionsExpr = fmt.Sprintf(
+			`openldap_cluster_connections{%s,type="current"} > %d`,
+			selector,
+			config.MaxConnections,
+		)
+	}
+
+	rules := []monitoringv1.Rule{
+		{
+			Alert:  "OpenldapExporterDown",
+			Expr:   intstr.FromString(downExpr),
+			For:    monitoringv1.Duration(config.For),
+			Labels: alertLabels,
+			Annotations: map[string]string{
+				"summary":     "Openldap exporter is down",
+				"description": "Exporter of pod {{ $labels.pod }} in cluster " + cluster.Name + " cannot be scraped",
+			},
+		},
+		{
+			Alert:  "OpenldapReplicationLag",
+			Expr:   intstr.FromString(lagExpr),
+			For:    monitoringv1.Duration(config.For),
+			Labels: alertLabels,
+			Annotations: map[string]string{
+				"summary":     "Openldap replication lag is high",
+				"description": fmt.Sprintf("Replicas of cluster %s are more than %d seconds behind", cluster.Name, config.ReplicationLagSeconds),
+			},
+		},
+		{
+			Alert:  "OpenldapConnectionSaturation",
+			Expr:   intstr.FromString(connectionsExpr),
+			For:    monitoringv1.Duration(config.For),
+			Labels: alertLabels,
+			Annotations: map[string]string{
+				"summary":     "Openldap connections are saturated",
+				"description": fmt.Sprintf("Pod {{ $labels.pod }} has more than %d current connections", config.MaxConnections),
+			},
+		},
+	}
+
+	// Replica cluster has no master until promoted
+	if !cluster.ReplicaEnabled() {
+		rules = append(rules, monitoringv1.Rule{
+			Alert: "OpenldapNoMaster",
+			Expr: intstr.FromString(fmt.Sprintf(
+				`sum(openldap_operator_current_master{namespace="%s",cluster="%s"}) < 1 or absent(openldap_operator_current_master{namespace="%s",cluster="%s"})`,
+				cluster.Namespace,
+				cluster.Name,
+				cluster.Namespace,
+				cluster.Name,
+			)),
+			For:    monitoringv1.Duration(config.For),
+			Labels: criticalLabels,
+			Annotations: map[string]string{
+				"summary":     "Openldap cluster has no master",
+				"description": "Cluster " + cluster.Name + " has no elected master",
+			},
+		})
+	}
+
+	// Providers accepting writes concurrently never converge on conflicts
+	if cluster.IsMultiProvider() {
+		rules = append(rules, monitoringv1.Rule{
+			Alert:  "OpenldapProviderConflict",
+			Expr:   intstr.FromString(conflictExpr),
+			For:    monitoringv1.Duration("10m"),
+			Labels: criticalLabels,
+			Annotations: map[string]string{
+				"summary": "Openldap providers diverged",
+				"description": fmt.Sprintf(
+					"Changes of server id {{ $labels.%s }} in cluster %s have not converged across providers",
+					conflictLabel,
+					cluster.Name,
+				),
+			},
+		})
+	}
+
+	if cluster.CertExpiryRuleEnabled() {
+		certExpiry := config.CertExpiry
+		rules = append(rules, monitoringv1.Rule{
+			Alert: "OpenldapCertificateExpiry",
+			Expr: intstr.FromString(fmt.Sprintf(
+				`(%s{secret_namespace="%s",secret_name="%s"} - time()) / 86400 < %d`,
+				certExpiry.Metric,
+				cluster.Namespace,
+				cluster.Spec.OpenldapConfig.Tls.SecretName,
+				certExpiry.Days,
+			)),
+			Labels: alertLabels,
+			Annotations: map[string]string{
+				"summary":     "Openldap tls certificate is near expiry",
+				"description": fmt.Sprintf("Certificate of cluster %s expires in less than %d days", cluster.Name, certExpiry.Days),
+			},
+		})
+	}
+
+	return &monitoringv1.PrometheusRule{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      cluster.Name,
+			Namespace: cluster.Namespace,
+			Labels: utils.MergeMap(
+				cluster.DefaultLabels(),
+				cluster.Spec.Monitor.Labels,
+				config.Labels,
+			),
+			Annotations: cluster.GetAnnotations(),
+		},
+		Spec: monitoringv1.PrometheusRuleSpec{
+			Groups: []monitoringv1.RuleGroup{
+				{
+					Name:  cluster.Name,
+					Rules: rules,
+				},
+			},
+		},
+	}
+}
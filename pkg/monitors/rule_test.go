@@ -0,0 +1,104 @@
+package monitors
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+
+	openldapv1 "github.com/qwp0905/openldap-operator/api/v1"
+	"github.com/qwp0905/openldap-operator/internal/fixtures"
+)
+
+func newRulesCluster() *openldapv1.OpenldapCluster {
+	cluster := fixtures.NewCluster("ldap", 3)
+	cluster.Spec.Monitor.Enabled = true
+	cluster.Spec.Monitor.Rules = &openldapv1.MonitorRulesConfig{Enabled: true}
+	cluster.SetDefault()
+
+	return cluster
+}
+
+// alerts returns the rendered rules by alert name.
+func alerts(rule *monitoringv1.PrometheusRule) map[string]monitoringv1.Rule {
+	rules := map[string]monitoringv1.Rule{}
+	for _, r := range rule.Spec.Groups[0].Rules {
+		rules[r.Alert] = r
+	}
+
+	return rules
+}
+
+var _ = Describe("PrometheusRule", func() {
+	It("selects the series of the cluster", func() {
+		rules := alerts(CreatePrometheusRule(newRulesCluster()))
+
+		Expect(rules["OpenldapExporterDown"].Expr.StrVal).To(ContainSubstring(`up{namespace="default",job="ldap"}`))
+		Expect(rules["OpenldapNoMaster"].Expr.StrVal).To(ContainSubstring(`openldap_operator_current_master{namespace="default",cluster="ldap"}`))
+		Expect(rules["OpenldapReplicationLag"].Expr.StrVal).To(HaveSuffix("> 60"))
+		Expect(rules["OpenldapConnectionSaturation"].Expr.StrVal).To(HaveSuffix("> 1000"))
+	})
+
+	It("adds the labels of the rules to every alert", func() {
+		cluster := newRulesCluster()
+		cluster.Spec.Monitor.Rules.Labels = map[string]string{"team": "directory"}
+
+		for _, r := range CreatePrometheusRule(cluster).Spec.Groups[0].Rules {
+			Expect(r.Labels).To(HaveKeyWithValue("team", "directory"))
+		}
+	})
+
+	Context("certificate expiry", func() {
+		It("is not alerted unless enabled", func() {
+			cluster := fixtures.WithTls(newRulesCluster())
+
+			Expect(alerts(CreatePrometheusRule(cluster))).NotTo(HaveKey("OpenldapCertificateExpiry"))
+		})
+
+		It("reads the configured metric of the tls secret", func() {
+			cluster := fixtures.WithTls(newRulesCluster())
+			cluster.Spec.Monitor.Rules.CertExpiry.Enabled = true
+			cluster.Spec.Monitor.Rules.CertExpiry.Metric = "cert_not_after"
+			cluster.Spec.Monitor.Rules.CertExpiry.Days = 7
+
+			expr := alerts(CreatePrometheusRule(cluster))["OpenldapCertificateExpiry"].Expr.StrVal
+			Expect(expr).To(Equal(`(cert_not_after{secret_namespace="default",secret_name="ldap-tls"} - time()) / 86400 < 7`))
+		})
+
+		It("is not alerted without tls", func() {
+			cluster := newRulesCluster()
+			cluster.Spec.Monitor.Rules.CertExpiry.Enabled = true
+
+			Expect(alerts(CreatePrometheusRule(cluster))).NotTo(HaveKey("OpenldapCertificateExpiry"))
+		})
+	})
+
+	Context("provider conflict", func() {
+		It("is only alerted with multiple providers", func() {
+			Expect(alerts(CreatePrometheusRule(newRulesCluster()))).NotTo(HaveKey("OpenldapProviderConflict"))
+		})
+
+		It("describes the server id label of the exporter", func() {
+			cluster := fixtures.WithTopology(newRulesCluster(), openldapv1.TopologyMultiProvider)
+
+			conflict := alerts(CreatePrometheusRule(cluster))["OpenldapProviderConflict"]
+			Expect(conflict.Expr.StrVal).To(HavePrefix("count by (id)"))
+			Expect(conflict.Annotations["description"]).To(HavePrefix("Changes of server id {{ $labels.id }} in cluster ldap"))
+		})
+
+		It("describes the server id label of the operator monitor", func() {
+			cluster := fixtures.WithTopology(newRulesCluster(), openldapv1.TopologyMultiProvider)
+			cluster.Spec.Monitor.Mode = openldapv1.MonitorModeOperator
+
+			conflict := alerts(CreatePrometheusRule(cluster))["OpenldapProviderConflict"]
+			Expect(conflict.Expr.StrVal).To(HavePrefix("count by (server_id)"))
+			Expect(conflict.Annotations["description"]).To(ContainSubstring("{{ $labels.server_id }}"))
+		})
+	})
+
+	It("does not alert a missing master of a replica cluster", func() {
+		cluster := fixtures.WithReplica(newRulesCluster(), "ldap://ldap.example.com")
+
+		Expect(alerts(CreatePrometheusRule(cluster))).NotTo(HaveKey("OpenldapNoMaster"))
+		Expect(alerts(CreatePrometheusRule(newRulesCluster()))).To(HaveKey("OpenldapNoMaster"))
+	})
+})
@@ -0,0 +1,220 @@
+package monitors
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+const (
+	monitorBase    = "cn=Monitor"
+	scrapeTimeout  = time.Second * 3
+	csnTimeFormat  = "20060102150405.000000Z"
+	monitorCounter = "monitorCounter"
+	opInitiated    = "monitorOpInitiated"
+	opCompleted    = "monitorOpCompleted"
+	monitoredInfo  = "monitoredInfo"
+	mdbEntries     = "olmMDBEntries"
+	contextCsn     = "contextCSN"
+)
+
+// MonitorSnapshot is a single read of the cn=Monitor backend of a pod.
+type MonitorSnapshot struct {
+	Connections map[string]float64
+	Operations  map[string]OperationCount
+	Threads     map[string]float64
+	Waiters     map[string]float64
+	Entries     float64
+	// ContextCsn holds the last change time of the suffix keyed by server id
+	ContextCsn map[string]time.Time
+}
+
+type OperationCount struct {
+	Initiated float64
+	Completed float64
+}
+
+type ScrapeTarget struct {
+	Url       string
+	TlsConfig *tls.Config
+	// StartTls upgrades the plain connection with TlsConfig before binding.
+	StartTls     bool
+	BindDn       string
+	BindPassword string
+	Root         string
+}
+
+func ScrapeMonitor(target ScrapeTarget) (*MonitorSnapshot, error) {
+	conn, err := ldap.DialURL(
+		target.Url,
+		ldap.DialWithDialer(&net.Dialer{Timeout: scrapeTimeout}),
+		ldap.DialWithTLSConfig(target.TlsConfig),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetTimeout(scrapeTimeout)
+
+	if target.StartTls {
+		if err = conn.StartTLS(target.TlsConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = conn.Bind(target.BindDn, target.BindPassword); err != nil {
+		return nil, err
+	}
+
+	result, err := conn.Search(ldap.NewSearchRequest(
+		monitorBase,
+		ldap.ScopeWholeSubtree,
+		ldap.NeverDerefAliases,
+		0,
+		int(scrapeTimeout.Seconds()),
+		false,
+		"(objectClass=*)",
+		[]string{monitorCounter, opInitiated, opCompleted, monitoredInfo, mdbEntries},
+		nil,
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &MonitorSnapshot{
+		Connections: map[string]float64{},
+		Operations:  map[string]OperationCount{},
+		Threads:     map[string]float64{},
+		Waiters:     map[string]float64{},
+		ContextCsn:  map[string]time.Time{},
+	}
+
+	for _, entry := range result.Entries {
+		name, parent := splitMonitorDn(entry.DN)
+
+		switch parent {
+		case "connections":
+			if name == "current" || name == "total" {
+				snapshot.Connections[name] = parseFloat(entry.GetAttributeValue(monitorCounter))
+			}
+		case "operations":
+			snapshot.Operations[name] = OperationCount{
+				Initiated: parseFloat(entry.GetAttributeValue(opInitiated)),
+				Completed: parseFloat(entry.GetAttributeValue(opCompleted)),
+			}
+		case "threads":
+			if value, err := strconv.ParseFloat(entry.GetAttributeValue(monitoredInfo), 64); err == nil {
+				snapshot.Threads[name] = value
+			}
+		case "waiters":
+			snapshot.Waiters[name] = parseFloat(entry.GetAttributeValue(monitorCounter))
+		case "databases":
+			snapshot.Entries += parseFloat(entry.GetAttributeValue(mdbEntries))
+		}
+	}
+
+	if snapshot.ContextCsn, err = searchContextCsn(conn, target.Root); err != nil {
+		return nil, err
+	}
+
+	return snapshot, nil
+}
+
+// ReadContextCsn returns the last change time of the root entry keyed by
+// server id, used to compare replication state with an external provider.
+func ReadContextCsn(target ScrapeTarget) (map[string]time.Time, error) {
+	conn, err := ldap.DialURL(
+		target.Url,
+		ldap.DialWithDialer(&net.Dialer{Timeout: scrapeTimeout}),
+		ldap.DialWithTLSConfig(target.TlsConfig),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetTimeout(scrapeTimeout)
+
+	if target.StartTls {
+		if err = conn.StartTLS(target.TlsConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = conn.Bind(target.BindDn, target.BindPassword); err != nil {
+		return nil, err
+	}
+
+	return searchContextCsn(conn, target.Root)
+}
+
+func searchContextCsn(conn *ldap.Conn, root string) (map[string]time.Time, error) {
+	result, err := conn.Search(ldap.NewSearchRequest(
+		root,
+		ldap.ScopeBaseObject,
+		ldap.NeverDerefAliases,
+		0,
+		int(scrapeTimeout.Seconds()),
+		false,
+		"(objectClass=*)",
+		[]string{contextCsn},
+		nil,
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	csns := map[string]time.Time{}
+	for _, entry := range result.Entries {
+		for _, csn := range entry.GetAttributeValues(contextCsn) {
+			serverId, changedAt, err := parseCsn(csn)
+			if err != nil {
+				continue
+			}
+
+			csns[serverId] = changedAt
+		}
+	}
+
+	return csns, nil
+}
+
+// splitMonitorDn returns the lowercase rdn value of the entry and of its parent,
+// e.g. "cn=Search,cn=Operations,cn=Monitor" returns "search" and "operations".
+func splitMonitorDn(dn string) (string, string) {
+	parsed, err := ldap.ParseDN(dn)
+	if err != nil || len(parsed.RDNs) < 3 {
+		return "", ""
+	}
+
+	return strings.ToLower(parsed.RDNs[0].Attributes[0].Value),
+		strings.ToLower(parsed.RDNs[1].Attributes[0].Value)
+}
+
+// parseCsn parses a csn like 20230603120000.000000Z#000000#001#000000
+func parseCsn(csn string) (string, time.Time, error) {
+	parts := strings.Split(csn, "#")
+	if len(parts) != 4 {
+		return "", time.Time{}, fmt.Errorf("invalid csn %s", csn)
+	}
+
+	changedAt, err := time.Parse(csnTimeFormat, parts[0])
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return parts[2], changedAt, nil
+}
+
+func parseFloat(value string) float64 {
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0
+	}
+
+	return parsed
+}
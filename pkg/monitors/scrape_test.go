@@ -0,0 +1,35 @@
+package monitors
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Monitor scrape", func() {
+	It("splits the monitor dn into the entry and its parent", func() {
+		name, parent := splitMonitorDn("cn=Search,cn=Operations,cn=Monitor")
+		Expect(name).To(Equal("search"))
+		Expect(parent).To(Equal("operations"))
+
+		name, parent = splitMonitorDn("cn=Monitor")
+		Expect(name).To(BeEmpty())
+		Expect(parent).To(BeEmpty())
+	})
+
+	It("parses the server id and the change time of a csn", func() {
+		serverId, changedAt, err := parseCsn("20230603120000.000000Z#000000#001#000000")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(serverId).To(Equal("001"))
+		Expect(changedAt).To(Equal(time.Date(2023, 6, 3, 12, 0, 0, 0, time.UTC)))
+
+		_, _, err = parseCsn("20230603120000.000000Z")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("reads unparsable counters as zero", func() {
+		Expect(parseFloat("42")).To(Equal(float64(42)))
+		Expect(parseFloat("")).To(Equal(float64(0)))
+	})
+})
@@ -0,0 +1,14 @@
+package monitors
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestMonitors(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	RunSpecs(t, "Monitors Suite")
+}
@@ -0,0 +1,167 @@
+package networkpolicies
+
+import (
+	openldapv1 "github.com/qwp0905/openldap-operator/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func CreateNetworkPolicy(
+	cluster *openldapv1.OpenldapCluster,
+	operatorNamespace string,
+) *networkingv1.NetworkPolicy {
+	ldapPorts := ldapPolicyPorts(cluster)
+	metricsPorts := []networkingv1.NetworkPolicyPort{policyPort(cluster.MetricsPort())}
+
+	clients := cluster.Spec.NetworkPolicy.From
+	if len(clients) == 0 {
+		clients = []networkingv1.NetworkPolicyPeer{
+			{PodSelector: &metav1.LabelSelector{}},
+		}
+	}
+
+	ingress := []networkingv1.NetworkPolicyIngressRule{
+		{
+			From: []networkingv1.NetworkPolicyPeer{
+				{
+					PodSelector: &metav1.LabelSelector{
+						MatchLabels: cluster.SelectorLabels(),
+					},
+				},
+			},
+			Ports: ldapPorts,
+		},
+		{
+			From:  clients,
+			Ports: ldapPorts,
+		},
+		{
+			From: []networkingv1.NetworkPolicyPeer{
+				{
+					PodSelector: &metav1.LabelSelector{
+						MatchLabels: cluster.JobLabels(),
+					},
+				},
+			},
+			Ports: ldapPorts,
+		},
+	}
+
+	if cluster.ExporterEnabled() && len(cluster.Spec.NetworkPolicy.MetricsFrom) > 0 {
+		ingress = append(ingress, networkingv1.NetworkPolicyIngressRule{
+			From:  cluster.Spec.NetworkPolicy.MetricsFrom,
+			Ports: metricsPorts,
+		})
+	}
+
+	if cluster.Spec.NetworkPolicy.OperatorNamespace != "" {
+		operatorNamespace = cluster.Spec.NetworkPolicy.OperatorNamespace
+	}
+
+	if operatorNamespace != "" {
+		ports := append([]networkingv1.NetworkPolicyPort{}, ldapPorts...)
+		if cluster.ExporterEnabled() {
+			ports = append(ports, metricsPorts...)
+		}
+
+		ingress = append(ingress, networkingv1.NetworkPolicyIngressRule{
+			From: []networkingv1.NetworkPolicyPeer{
+				{
+					NamespaceSelector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{
+							"kubernetes.io/metadata.name": operatorNamespace,
+						},
+					},
+				},
+			},
+			Ports: ports,
+		})
+	}
+
+	return &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        cluster.Name,
+			Namespace:   cluster.Namespace,
+			Labels:      cluster.DefaultLabels(),
+			Annotations: cluster.GetAnnotations(),
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{
+				MatchLabels: cluster.SelectorLabels(),
+			},
+			PolicyTypes: []networkingv1.PolicyType{
+				networkingv1.PolicyTypeIngress,
+				networkingv1.PolicyTypeEgress,
+			},
+			Ingress: ingress,
+			Egress:  createEgressRules(cluster),
+		},
+	}
+}
+
+// createEgressRules allows dns and the replication between the pods of the
+// cluster, followed by the destinations configured for the cluster.
+func createEgressRules(cluster *openldapv1.OpenldapCluster) []networkingv1.NetworkPolicyEgressRule {
+	udp := corev1.ProtocolUDP
+	dnsPorts := []networkingv1.NetworkPolicyPort{
+		policyPort(53),
+		{
+			Protocol: &udp,
+			Port:     &intstr.IntOrString{Type: intstr.Int, IntVal: 53},
+		},
+	}
+
+	egress := []networkingv1.NetworkPolicyEgressRule{
+		{
+			To: []networkingv1.NetworkPolicyPeer{
+				{
+					NamespaceSelector: &metav1.LabelSelector{},
+					PodSelector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{"k8s-app": "kube-dns"},
+					},
+				},
+			},
+			Ports: dnsPorts,
+		},
+		{
+			To: []networkingv1.NetworkPolicyPeer{
+				{
+					PodSelector: &metav1.LabelSelector{
+						MatchLabels: cluster.SelectorLabels(),
+					},
+				},
+			},
+			Ports: ldapPolicyPorts(cluster),
+		},
+	}
+
+	return append(egress, cluster.Spec.NetworkPolicy.Egress...)
+}
+
+func ldapPolicyPorts(cluster *openldapv1.OpenldapCluster) []networkingv1.NetworkPolicyPort {
+	ports := []networkingv1.NetworkPolicyPort{}
+
+	if !cluster.LdapsOnly() {
+		ports = append(ports, policyPort(cluster.LdapPort()))
+	}
+
+	if cluster.TlsEnabled() {
+		ports = append(ports, policyPort(cluster.LdapsPort()))
+	}
+
+	return ports
+}
+
+func policyPort(port int32) networkingv1.NetworkPolicyPort {
+	protocol := corev1.ProtocolTCP
+
+	return networkingv1.NetworkPolicyPort{
+		Protocol: &protocol,
+		Port: &intstr.IntOrString{
+			Type:   intstr.Int,
+			IntVal: port,
+		},
+	}
+}
@@ -0,0 +1,66 @@
+package networkpolicies
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	networkingv1 "k8s.io/api/networking/v1"
+
+	openldapv1 "github.com/qwp0905/openldap-operator/api/v1"
+	"github.com/qwp0905/openldap-operator/internal/fixtures"
+)
+
+// operatorNamespaces returns the namespaces allowed by name in the ingress
+// rules of the policy.
+func operatorNamespaces(policy *networkingv1.NetworkPolicy) []string {
+	namespaces := []string{}
+	for _, rule := range policy.Spec.Ingress {
+		for _, peer := range rule.From {
+			if peer.NamespaceSelector != nil {
+				namespaces = append(namespaces, peer.NamespaceSelector.MatchLabels["kubernetes.io/metadata.name"])
+			}
+		}
+	}
+
+	return namespaces
+}
+
+func newPolicyCluster() *openldapv1.OpenldapCluster {
+	cluster := fixtures.NewCluster("ldap", 3)
+	cluster.Spec.NetworkPolicy.Enabled = true
+	cluster.SetDefault()
+
+	return cluster
+}
+
+var _ = Describe("NetworkPolicy", func() {
+	It("allows the namespace the operator runs in", func() {
+		policy := CreateNetworkPolicy(newPolicyCluster(), "openldap-operator")
+
+		Expect(operatorNamespaces(policy)).To(ContainElement("openldap-operator"))
+	})
+
+	It("allows the operator namespace of the cluster instead", func() {
+		cluster := newPolicyCluster()
+		cluster.Spec.NetworkPolicy.OperatorNamespace = "custom"
+		policy := CreateNetworkPolicy(cluster, "openldap-operator")
+
+		Expect(operatorNamespaces(policy)).To(ContainElement("custom"))
+		Expect(operatorNamespaces(policy)).NotTo(ContainElement("openldap-operator"))
+	})
+
+	It("restricts egress to dns, the other pods and the configured destinations", func() {
+		cluster := newPolicyCluster()
+		cluster.Spec.NetworkPolicy.Egress = []networkingv1.NetworkPolicyEgressRule{
+			{To: []networkingv1.NetworkPolicyPeer{{IPBlock: &networkingv1.IPBlock{CIDR: "10.0.0.0/8"}}}},
+		}
+		policy := CreateNetworkPolicy(cluster, "")
+
+		Expect(policy.Spec.PolicyTypes).To(ContainElement(networkingv1.PolicyTypeEgress))
+		Expect(policy.Spec.Egress).To(HaveLen(3))
+		Expect(policy.Spec.Egress[0].Ports).To(HaveLen(2))
+		Expect(policy.Spec.Egress[0].Ports[0].Port.IntVal).To(Equal(int32(53)))
+		Expect(policy.Spec.Egress[1].To[0].PodSelector.MatchLabels).To(Equal(cluster.SelectorLabels()))
+		Expect(policy.Spec.Egress[1].Ports[0].Port.IntVal).To(Equal(cluster.LdapPort()))
+		Expect(policy.Spec.Egress[2].To[0].IPBlock.CIDR).To(Equal("10.0.0.0/8"))
+	})
+})
@@ -0,0 +1,25 @@
+package pdbs
+
+import (
+	openldapv1 "github.com/qwp0905/openldap-operator/api/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func CreatePodDisruptionBudget(cluster *openldapv1.OpenldapCluster) *policyv1.PodDisruptionBudget {
+	return &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        cluster.Name,
+			Namespace:   cluster.Namespace,
+			Labels:      cluster.DefaultLabels(),
+			Annotations: cluster.GetAnnotations(),
+		},
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			Selector: &metav1.LabelSelector{
+				MatchLabels: cluster.SelectorLabels(),
+			},
+			MinAvailable:   cluster.Spec.PodDisruptionBudget.MinAvailable,
+			MaxUnavailable: cluster.Spec.PodDisruptionBudget.MaxUnavailable,
+		},
+	}
+}
@@ -0,0 +1,32 @@
+package pdbs
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/qwp0905/openldap-operator/internal/fixtures"
+)
+
+var _ = Describe("PodDisruptionBudget", func() {
+	It("allows one pod unavailable by default", func() {
+		cluster := fixtures.NewCluster("ldap", 3)
+		pdb := CreatePodDisruptionBudget(cluster)
+
+		Expect(pdb.Spec.Selector.MatchLabels).To(Equal(cluster.SelectorLabels()))
+		Expect(pdb.Spec.MaxUnavailable).To(Equal(&intstr.IntOrString{IntVal: 1}))
+		Expect(pdb.Spec.MinAvailable).To(BeNil())
+	})
+
+	It("keeps minAvailable if set", func() {
+		cluster := fixtures.NewCluster("ldap", 3)
+		cluster.Spec.PodDisruptionBudget.MaxUnavailable = nil
+		minAvailable := intstr.FromString("50%")
+		cluster.Spec.PodDisruptionBudget.MinAvailable = &minAvailable
+		cluster.SetDefault()
+		pdb := CreatePodDisruptionBudget(cluster)
+
+		Expect(pdb.Spec.MinAvailable).To(Equal(&minAvailable))
+		Expect(pdb.Spec.MaxUnavailable).To(BeNil())
+	})
+})
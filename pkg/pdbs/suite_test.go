@@ -0,0 +1,14 @@
+package pdbs
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestPdbs(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	RunSpecs(t, "Pdbs Suite")
+}
@@ -7,47 +7,55 @@ import (
 	openldapv1 "github.com/qwp0905/openldap-operator/api/v1"
 	"github.com/qwp0905/openldap-operator/pkg/utils"
 	corev1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 func CreateExporterContainer(cluster *openldapv1.OpenldapCluster) corev1.Container {
-	return corev1.Container{
-		Name:            cluster.ExporterName(),
-		Image:           cluster.ExporterImage(),
-		ImagePullPolicy: corev1.PullIfNotPresent,
-		Resources: corev1.ResourceRequirements{
-			Limits: corev1.ResourceList{
-				"cpu":    resource.MustParse("50m"),
-				"memory": resource.MustParse("64Mi"),
-			},
-			Requests: corev1.ResourceList{
-				"cpu":    resource.MustParse("50m"),
-				"memory": resource.MustParse("64Mi"),
+	exporter := cluster.GetExporter()
+	envVars := []corev1.EnvVar{
+		{
+			Name: "BIND_PW",
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: cluster.ExporterBindPassword(),
 			},
 		},
-		Env: []corev1.EnvVar{
-			{
-				Name: "BIND_PW",
-				ValueFrom: &corev1.EnvVarSource{
-					SecretKeyRef: cluster.Spec.OpenldapConfig.AdminPassword,
-				},
-			},
-			{
-				Name:  "BIND_DN",
-				Value: cluster.AdminDn(),
-			},
-			{
-				Name:  "METRICS_PORT",
-				Value: strconv.Itoa(int(cluster.MetricsPort())),
-			},
-			{
-				Name:  "LDAP_PORT",
-				Value: strconv.Itoa(int(cluster.LdapPort())),
-			},
+		{
+			Name:  "BIND_DN",
+			Value: cluster.ExporterBindDn(),
+		},
+		{
+			Name:  "METRICS_PORT",
+			Value: strconv.Itoa(int(cluster.MetricsPort())),
+		},
+		{
+			Name:  "LDAP_PORT",
+			Value: strconv.Itoa(int(cluster.ServingPort())),
+		},
+		{
+			Name:  "LDAP_TLS",
+			Value: utils.ConvertBool(cluster.LdapsOnly()),
+		},
+		{
+			Name:  "LDAP_STARTTLS",
+			Value: utils.ConvertBool(cluster.StartTlsRequired()),
 		},
+	}
+
+	if len(exporter.Env) > 0 {
+		envVars = append(envVars, exporter.Env...)
+	}
+
+	return corev1.Container{
+		Name:            cluster.ExporterName(),
+		Image:           cluster.ExporterImage(),
+		ImagePullPolicy: exporter.ImagePullPolicy,
+		Resources:       exporter.Resources,
+		Args:            exporter.Args,
+		Env:             envVars,
+		SecurityContext: exporter.SecurityContext,
 		Ports: []corev1.ContainerPort{
 			{
 				Name:          cluster.MetricsPortName(),
+				Protocol:      "TCP",
 				ContainerPort: cluster.MetricsPort(),
 			},
 		},
@@ -99,14 +107,15 @@ func DefaultEnvs(cluster *openldapv1.OpenldapCluster) []corev1.EnvVar {
 		{Name: "LDAP_CONFIG_ADMIN_ENABLED", Value: "yes"},
 		{Name: "LDAP_ADMIN_USERNAME", Value: cluster.Spec.OpenldapConfig.AdminUsername},
 		{Name: "LDAP_CONFIG_ADMIN_USERNAME", Value: cluster.Spec.OpenldapConfig.ConfigUsername},
-		{
-			Name: "MASTER_HOST", Value: fmt.Sprintf(
-				"ldap://%s.%s.svc.cluster.local:%s",
-				cluster.WriteServiceName(),
-				cluster.Namespace,
-				strconv.Itoa(int(cluster.LdapPort())),
-			),
-		},
+	}
+
+	// replication of the image is left out once the operator writes syncrepl
+	if !cluster.ReplicationManaged() {
+		envVars = append(envVars, corev1.EnvVar{Name: "MASTER_HOST", Value: masterHost(cluster)})
+
+		if cluster.ReplicaEnabled() {
+			envVars = append(envVars, replicaEnvs(cluster)...)
+		}
 	}
 
 	if cluster.Spec.OpenldapConfig.ConfigPassword != nil {
@@ -128,6 +137,10 @@ func DefaultEnvs(cluster *openldapv1.OpenldapCluster) []corev1.EnvVar {
 				Name:  "LDAP_LDAPS_PORT_NUMBER",
 				Value: strconv.Itoa(int(cluster.LdapsPort())),
 			},
+			{
+				Name:  "LDAP_REQUIRE_TLS",
+				Value: utils.ConvertBool(cluster.RequireTls()),
+			},
 			{
 				Name: "LDAP_TLS_CERT_FILE",
 				Value: fmt.Sprintf(
@@ -160,13 +173,39 @@ func DefaultEnvs(cluster *openldapv1.OpenldapCluster) []corev1.EnvVar {
 	return envVars
 }
 
+// InitCommand runs the setup of the image. For delta-syncrepl the directory of
+// the accesslog database is created after, owned by the user of the data.
+func InitCommand(cluster *openldapv1.OpenldapCluster) []string {
+	setup := "/opt/bitnami/scripts/openldap/setup.sh"
+	if !cluster.DeltaSyncreplEnabled() {
+		return []string{setup}
+	}
+
+	return []string{
+		"/bin/bash",
+		"-c",
+		fmt.Sprintf(
+			"%s && mkdir -p %s && chown --reference=%s/data %s",
+			setup,
+			cluster.AccesslogPath(),
+			DataVolumeMounts(cluster).MountPath,
+			cluster.AccesslogPath(),
+		),
+	}
+}
+
 func ContainerPorts(cluster *openldapv1.OpenldapCluster) []corev1.ContainerPort {
-	ports := []corev1.ContainerPort{
-		{
-			Name:          "ldap",
-			Protocol:      "TCP",
-			ContainerPort: cluster.LdapPort(),
-		},
+	ports := []corev1.ContainerPort{}
+
+	if !cluster.LdapsOnly() {
+		ports = append(
+			ports,
+			corev1.ContainerPort{
+				Name:          "ldap",
+				Protocol:      "TCP",
+				ContainerPort: cluster.LdapPort(),
+			},
+		)
 	}
 
 	if cluster.TlsEnabled() {
@@ -196,3 +235,61 @@ func SeedVolumeMount(cluster *openldapv1.OpenldapCluster) corev1.VolumeMount {
 		MountPath: cluster.SeedDataPath(),
 	}
 }
+
+// masterHost is the provider replicated from by the image itself, the
+// external source for a replica cluster. It stays on the write service
+// otherwise, a per-pod name of the master would change the pod template and
+// restart every pod on each failover.
+func masterHost(cluster *openldapv1.OpenldapCluster) string {
+	if cluster.ReplicaEnabled() {
+		return cluster.ReplicaSourceUrl()
+	}
+
+	return cluster.WriteServiceUrl()
+}
+
+func replicaEnvs(cluster *openldapv1.OpenldapCluster) []corev1.EnvVar {
+	source := cluster.GetReplicaSource()
+	envVars := []corev1.EnvVar{
+		{Name: "LDAP_REPLICA_SOURCE", Value: "yes"},
+		{Name: "LDAP_REPLICA_BIND_DN", Value: source.BindDn},
+		{
+			Name: "LDAP_REPLICA_BIND_PASSWORD",
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: source.BindPassword,
+			},
+		},
+		{Name: "LDAP_REPLICA_SEARCH_BASE", Value: cluster.ReplicaSearchBase()},
+	}
+
+	if source.Ca != nil {
+		envVars = append(envVars, corev1.EnvVar{
+			Name:  "LDAP_REPLICA_CA_FILE",
+			Value: fmt.Sprintf("%s/%s", cluster.ReplicaCaMountPath(), source.Ca.Key),
+		})
+	}
+
+	return envVars
+}
+
+func ReplicaCaVolume(cluster *openldapv1.OpenldapCluster) corev1.Volume {
+	ca := cluster.GetReplicaSource().Ca
+
+	return corev1.Volume{
+		Name: "replica-ca",
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName: ca.Name,
+				Items:      []corev1.KeyToPath{{Key: ca.Key, Path: ca.Key}},
+			},
+		},
+	}
+}
+
+func ReplicaCaVolumeMount(cluster *openldapv1.OpenldapCluster) corev1.VolumeMount {
+	return corev1.VolumeMount{
+		Name:      "replica-ca",
+		MountPath: cluster.ReplicaCaMountPath(),
+		ReadOnly:  true,
+	}
+}
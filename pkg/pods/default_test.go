@@ -0,0 +1,135 @@
+package pods
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+
+	openldapv1 "github.com/qwp0905/openldap-operator/api/v1"
+	"github.com/qwp0905/openldap-operator/internal/fixtures"
+)
+
+// envValue returns the value of the env var, empty if not set.
+func envValue(envVars []corev1.EnvVar, name string) string {
+	for _, env := range envVars {
+		if env.Name == name {
+			return env.Value
+		}
+	}
+
+	return ""
+}
+
+var _ = Describe("Exporter container", func() {
+	It("scrapes the plain port without tls", func() {
+		container := CreateExporterContainer(fixtures.NewCluster("ldap", 3))
+
+		Expect(envValue(container.Env, "LDAP_PORT")).To(Equal("1389"))
+		Expect(envValue(container.Env, "LDAP_TLS")).To(Equal("no"))
+		Expect(envValue(container.Env, "LDAP_STARTTLS")).To(Equal("no"))
+	})
+
+	It("upgrades the plain port with StartTLS if tls is required", func() {
+		cluster := fixtures.WithTls(fixtures.NewCluster("ldap", 3))
+		cluster.Spec.OpenldapConfig.Tls.RequireTls = true
+		container := CreateExporterContainer(cluster)
+
+		Expect(envValue(container.Env, "LDAP_PORT")).To(Equal("1389"))
+		Expect(envValue(container.Env, "LDAP_TLS")).To(Equal("no"))
+		Expect(envValue(container.Env, "LDAP_STARTTLS")).To(Equal("yes"))
+	})
+
+	It("scrapes the ldaps port if the plain port is dropped", func() {
+		cluster := fixtures.WithTls(fixtures.NewCluster("ldap", 3))
+		cluster.Spec.OpenldapConfig.Tls.RequireTls = true
+		cluster.Spec.OpenldapConfig.Tls.LdapsOnly = true
+		container := CreateExporterContainer(cluster)
+
+		Expect(envValue(container.Env, "LDAP_PORT")).To(Equal("1636"))
+		Expect(envValue(container.Env, "LDAP_TLS")).To(Equal("yes"))
+		Expect(envValue(container.Env, "LDAP_STARTTLS")).To(Equal("no"))
+	})
+})
+
+var _ = Describe("Exporter overrides", func() {
+	It("binds as admin by default", func() {
+		cluster := fixtures.NewCluster("ldap", 3)
+		container := CreateExporterContainer(cluster)
+
+		Expect(container.Image).To(Equal("qwp1216/openldap-exporter:0.0.4"))
+		Expect(container.Ports[0].ContainerPort).To(Equal(int32(9142)))
+		Expect(envValue(container.Env, "BIND_DN")).To(Equal(cluster.AdminDn()))
+		Expect(container.Env[0].ValueFrom.SecretKeyRef).
+			To(Equal(cluster.Spec.OpenldapConfig.AdminPassword))
+	})
+
+	It("applies the exporter config", func() {
+		cluster := fixtures.NewCluster("ldap", 3)
+		password := &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "monitor"},
+			Key:                  "password",
+		}
+		cluster.Spec.Monitor.Exporter.Image = "example/exporter:1.0.0"
+		cluster.Spec.Monitor.Exporter.Port = 9300
+		cluster.Spec.Monitor.Exporter.BindDn = "cn=monitor,dc=example,dc=com"
+		cluster.Spec.Monitor.Exporter.BindPassword = password
+		cluster.Spec.Monitor.Exporter.Env = []corev1.EnvVar{{Name: "LOG_LEVEL", Value: "debug"}}
+		container := CreateExporterContainer(cluster)
+
+		Expect(container.Image).To(Equal("example/exporter:1.0.0"))
+		Expect(container.Ports[0].ContainerPort).To(Equal(int32(9300)))
+		Expect(envValue(container.Env, "METRICS_PORT")).To(Equal("9300"))
+		Expect(envValue(container.Env, "BIND_DN")).To(Equal("cn=monitor,dc=example,dc=com"))
+		Expect(envValue(container.Env, "LOG_LEVEL")).To(Equal("debug"))
+		Expect(container.Env[0].ValueFrom.SecretKeyRef).To(Equal(password))
+	})
+})
+
+var _ = Describe("Replica envs", func() {
+	It("replicates from the write service", func() {
+		cluster := fixtures.NewCluster("ldap", 3)
+		envVars := DefaultEnvs(cluster)
+
+		Expect(envValue(envVars, "MASTER_HOST")).To(Equal("ldap://ldap-write.default.svc.cluster.local:1389"))
+		Expect(envValue(envVars, "LDAP_REPLICA_SOURCE")).To(BeEmpty())
+	})
+
+	It("replicates from the source of a replica cluster", func() {
+		cluster := fixtures.WithReplica(fixtures.NewCluster("ldap", 3), "ldaps://ldap.example.com:636")
+		cluster.Spec.Replica.Source.SearchBase = "ou=people,dc=example,dc=com"
+		cluster.Spec.Replica.Source.Ca = &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "source-ca"},
+			Key:                  "ca.crt",
+		}
+		envVars := DefaultEnvs(cluster)
+
+		Expect(envValue(envVars, "MASTER_HOST")).To(Equal("ldaps://ldap.example.com:636"))
+		Expect(envValue(envVars, "LDAP_REPLICA_SOURCE")).To(Equal("yes"))
+		Expect(envValue(envVars, "LDAP_REPLICA_BIND_DN")).To(Equal("cn=replicator,dc=example,dc=com"))
+		Expect(envValue(envVars, "LDAP_REPLICA_SEARCH_BASE")).To(Equal("ou=people,dc=example,dc=com"))
+		Expect(envValue(envVars, "LDAP_REPLICA_CA_FILE")).To(Equal("/opt/replica/certs/ca.crt"))
+		Expect(ReplicaCaVolume(cluster).Secret.SecretName).To(Equal("source-ca"))
+	})
+})
+
+var _ = Describe("Managed replication", func() {
+	It("leaves the replication of the image out", func() {
+		cluster := fixtures.WithReplica(fixtures.NewCluster("ldap", 3), "ldap://ldap.example.com")
+		cluster.Spec.Replication.Enabled = true
+		envVars := DefaultEnvs(cluster)
+
+		for _, name := range []string{"MASTER_HOST", "LDAP_REPLICA_SOURCE", "LDAP_REPLICATION_MANAGED"} {
+			Expect(envVars).NotTo(ContainElement(HaveField("Name", name)))
+		}
+	})
+
+	It("creates the accesslog directory for delta-syncrepl", func() {
+		cluster := fixtures.NewCluster("ldap", 3)
+		Expect(InitCommand(cluster)).To(Equal([]string{"/opt/bitnami/scripts/openldap/setup.sh"}))
+
+		cluster.Spec.Replication.Enabled = true
+		cluster.Spec.Replication.Mode = openldapv1.ReplicationDeltaSyncrepl
+		command := InitCommand(cluster)
+		Expect(command[2]).To(HavePrefix("/opt/bitnami/scripts/openldap/setup.sh && mkdir -p /bitnami/openldap/accesslog"))
+	})
+})
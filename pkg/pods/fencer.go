@@ -0,0 +1,133 @@
+package pods
+
+import (
+	"fmt"
+	"strconv"
+
+	openldapv1 "github.com/qwp0905/openldap-operator/api/v1"
+	"github.com/qwp0905/openldap-operator/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// fencerScript polls the master lease. Once the pod held the lease and then
+// sees another holder, or cannot confirm it for half of the lease duration,
+// the database is made read-only. The operator waits for the whole lease
+// duration before promoting another pod. The read-only flag persisted from a
+// previous run is cleared on the first successful check after restart. The
+// plain port is upgraded with StartTLS when the cluster requires tls.
+const fencerScript = `
+token_path=/var/run/secrets/kubernetes.io/serviceaccount
+api="https://kubernetes.default.svc/apis/coordination.k8s.io/v1/namespaces/${NAMESPACE}/leases/${LEASE_NAME}"
+held=false
+fenced=unknown
+last=$(date +%s)
+
+starttls=""
+if [ "${LDAP_STARTTLS}" = yes ]; then
+  starttls=-ZZ
+fi
+
+set_read_only() {
+  ldapmodify -x ${starttls} -H "${LDAP_URL}" \
+    -D "cn=${LDAP_CONFIG_ADMIN_USERNAME},cn=config" \
+    -w "${LDAP_CONFIG_ADMIN_PASSWORD}" >/dev/null <<EOF
+dn: ${FENCE_DATABASE_DN}
+changetype: modify
+replace: olcReadOnly
+olcReadOnly: $1
+EOF
+}
+
+while true; do
+  sleep 5
+  now=$(date +%s)
+
+  if lease=$(curl -sf --max-time 3 --cacert "${token_path}/ca.crt" \
+    -H "Authorization: Bearer $(cat ${token_path}/token)" "${api}"); then
+    holder=$(echo "${lease}" | grep -o '"holderIdentity": *"[^"]*"' | sed 's/.*"\([^"]*\)"$/\1/')
+
+    if [ "${holder}" = "${POD_NAME}" ]; then
+      held=true
+      last=${now}
+      if [ "${fenced}" != false ] && set_read_only FALSE; then
+        fenced=false
+      fi
+    elif [ "${held}" = true ]; then
+      if set_read_only TRUE; then
+        echo "lease taken by ${holder}, database fenced"
+        held=false
+        fenced=true
+      fi
+    elif [ "${fenced}" = unknown ] && set_read_only FALSE; then
+      fenced=false
+    fi
+  elif [ "${held}" = true ] && [ $((now - last)) -ge $((LEASE_DURATION_SECONDS / 2)) ]; then
+    if set_read_only TRUE; then
+      echo "lease not confirmed since ${last}, database fenced"
+      held=false
+      fenced=true
+    fi
+  fi
+done
+`
+
+func CreateFencerContainer(cluster *openldapv1.OpenldapCluster) corev1.Container {
+	fencing := cluster.GetFencing()
+
+	return corev1.Container{
+		Name:            cluster.FencerName(),
+		Image:           cluster.GetTemplate().Image,
+		ImagePullPolicy: cluster.GetTemplate().ImagePullPolicy,
+		Resources:       fencing.Resources,
+		Command:         []string{"/bin/bash", "-c", fencerScript},
+		Env: []corev1.EnvVar{
+			{
+				Name: "POD_NAME",
+				ValueFrom: &corev1.EnvVarSource{
+					FieldRef: &corev1.ObjectFieldSelector{
+						APIVersion: "v1",
+						FieldPath:  "metadata.name",
+					},
+				},
+			},
+			{
+				Name: "NAMESPACE",
+				ValueFrom: &corev1.EnvVarSource{
+					FieldRef: &corev1.ObjectFieldSelector{
+						APIVersion: "v1",
+						FieldPath:  "metadata.namespace",
+					},
+				},
+			},
+			{Name: "LEASE_NAME", Value: cluster.LeaseName()},
+			{
+				Name:  "LEASE_DURATION_SECONDS",
+				Value: strconv.Itoa(int(fencing.LeaseDurationSeconds)),
+			},
+			{Name: "FENCE_DATABASE_DN", Value: fencing.DatabaseDn},
+			{
+				Name: "LDAP_URL",
+				Value: fmt.Sprintf(
+					"%s://127.0.0.1:%s",
+					cluster.ServingScheme(),
+					strconv.Itoa(int(cluster.ServingPort())),
+				),
+			},
+			{
+				Name:  "LDAP_STARTTLS",
+				Value: utils.ConvertBool(cluster.StartTlsRequired()),
+			},
+			{Name: "LDAPTLS_REQCERT", Value: "never"},
+			{
+				Name:  "LDAP_CONFIG_ADMIN_USERNAME",
+				Value: cluster.Spec.OpenldapConfig.ConfigUsername,
+			},
+			{
+				Name: "LDAP_CONFIG_ADMIN_PASSWORD",
+				ValueFrom: &corev1.EnvVarSource{
+					SecretKeyRef: cluster.Spec.OpenldapConfig.ConfigPassword,
+				},
+			},
+		},
+	}
+}
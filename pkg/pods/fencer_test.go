@@ -0,0 +1,41 @@
+package pods
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/qwp0905/openldap-operator/internal/fixtures"
+)
+
+var _ = Describe("Fencer container", func() {
+	It("watches the master lease of the cluster", func() {
+		cluster := fixtures.NewCluster("ldap", 3)
+		cluster.Spec.Election.Fencing.Enabled = true
+		container := CreateFencerContainer(cluster)
+
+		Expect(envValue(container.Env, "LEASE_NAME")).To(Equal("ldap-master"))
+		Expect(envValue(container.Env, "LEASE_DURATION_SECONDS")).To(Equal("30"))
+		Expect(envValue(container.Env, "LDAP_URL")).To(Equal("ldap://127.0.0.1:1389"))
+		Expect(envValue(container.Env, "LDAP_STARTTLS")).To(Equal("no"))
+	})
+
+	It("upgrades the plain port with StartTLS if tls is required", func() {
+		cluster := fixtures.WithTls(fixtures.NewCluster("ldap", 3))
+		cluster.Spec.OpenldapConfig.Tls.RequireTls = true
+		container := CreateFencerContainer(cluster)
+
+		Expect(envValue(container.Env, "LDAP_URL")).To(Equal("ldap://127.0.0.1:1389"))
+		Expect(envValue(container.Env, "LDAP_STARTTLS")).To(Equal("yes"))
+		Expect(container.Command[2]).To(ContainSubstring("ldapmodify -x ${starttls}"))
+	})
+
+	It("connects to the ldaps port if the plain port is dropped", func() {
+		cluster := fixtures.WithTls(fixtures.NewCluster("ldap", 3))
+		cluster.Spec.OpenldapConfig.Tls.RequireTls = true
+		cluster.Spec.OpenldapConfig.Tls.LdapsOnly = true
+		container := CreateFencerContainer(cluster)
+
+		Expect(envValue(container.Env, "LDAP_URL")).To(Equal("ldaps://127.0.0.1:1636"))
+		Expect(envValue(container.Env, "LDAP_STARTTLS")).To(Equal("no"))
+	})
+})
@@ -0,0 +1,14 @@
+package pods
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestPods(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	RunSpecs(t, "Pods Suite")
+}
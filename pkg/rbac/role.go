@@ -25,6 +25,12 @@ func CreateRole(cluster *openldapv1.OpenldapCluster) *rbacv1.Role {
 				Resources: []string{"pods/exec"},
 				Verbs:     []string{"create"},
 			},
+			{
+				APIGroups:     []string{"coordination.k8s.io"},
+				Resources:     []string{"leases"},
+				ResourceNames: []string{cluster.LeaseName()},
+				Verbs:         []string{"get"},
+			},
 		},
 	}
 }
@@ -0,0 +1,20 @@
+package rbac
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/qwp0905/openldap-operator/internal/fixtures"
+)
+
+var _ = Describe("Role", func() {
+	It("grants read on the master lease of the cluster only", func() {
+		cluster := fixtures.NewCluster("ldap", 3)
+		role := CreateRole(cluster)
+
+		rule := role.Rules[len(role.Rules)-1]
+		Expect(rule.Resources).To(Equal([]string{"leases"}))
+		Expect(rule.ResourceNames).To(Equal([]string{cluster.LeaseName()}))
+		Expect(rule.Verbs).To(Equal([]string{"get"}))
+	})
+})
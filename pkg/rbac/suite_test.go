@@ -0,0 +1,14 @@
+package rbac
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestRbac(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	RunSpecs(t, "Rbac Suite")
+}
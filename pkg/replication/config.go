@@ -0,0 +1,272 @@
+package replication
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+const (
+	configBase    = "cn=config"
+	configDbDn    = "olcDatabase={0}config,cn=config"
+	configTimeout = time.Second * 5
+	modulePath    = "/opt/bitnami/openldap/lib/openldap"
+)
+
+type Target struct {
+	Url       string
+	TlsConfig *tls.Config
+	// StartTls upgrades the plain connection with TlsConfig before binding.
+	StartTls     bool
+	BindDn       string
+	BindPassword string
+}
+
+// Apply writes the replication config of a pod through cn=config. Overlays
+// are added to every pod, so that any of them can be promoted to provider. A
+// pod consuming cn=config from the seed only gets its own syncrepl, the
+// modules and overlays of the seed are replicated to it instead, adding them
+// on both would conflict.
+func Apply(target Target, config PodConfig) error {
+	conn, err := ldap.DialURL(
+		target.Url,
+		ldap.DialWithDialer(&net.Dialer{Timeout: configTimeout}),
+		ldap.DialWithTLSConfig(target.TlsConfig),
+	)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetTimeout(configTimeout)
+
+	if target.StartTls {
+		if err = conn.StartTLS(target.TlsConfig); err != nil {
+			return err
+		}
+	}
+
+	if err = conn.Bind(target.BindDn, target.BindPassword); err != nil {
+		return err
+	}
+
+	if config.ServerIds != nil {
+		serverIds := ldap.NewModifyRequest(configBase, nil)
+		serverIds.Replace("olcServerID", config.ServerIds)
+		if err = conn.Modify(serverIds); err != nil {
+			return err
+		}
+	}
+
+	if config.ConfigConsumer() {
+		if err = replaceSyncrepl(conn, configDbDn, config.ConfigSyncrepl, false); err != nil {
+			return err
+		}
+
+		return replaceSyncrepl(conn, config.DatabaseDn, config.Syncrepl, config.MirrorMode)
+	}
+
+	modules := []string{"syncprov.so"}
+	if config.Accesslog {
+		modules = append(modules, "accesslog.so")
+	}
+
+	if err = ensureModules(conn, modules); err != nil {
+		return err
+	}
+
+	if err = ensureOverlay(conn, config.DatabaseDn, "syncprov", "olcSyncProvConfig", map[string][]string{
+		"olcSpCheckpoint": {"100 10"},
+		"olcSpSessionLog": {"100"},
+	}); err != nil {
+		return err
+	}
+
+	if config.Accesslog {
+		if err = ensureAccesslog(conn, config); err != nil {
+			return err
+		}
+	}
+
+	if config.ConfigSeed {
+		if err = applyConfigSeed(conn); err != nil {
+			return err
+		}
+	}
+
+	return replaceSyncrepl(conn, config.DatabaseDn, config.Syncrepl, config.MirrorMode)
+}
+
+// applyConfigSeed provides cn=config to the other pods, a seed promoted from
+// consumer stops consuming.
+func applyConfigSeed(conn *ldap.Conn) error {
+	if err := ensureOverlay(conn, configDbDn, "syncprov", "olcSyncProvConfig", nil); err != nil {
+		return err
+	}
+
+	return replaceSyncrepl(conn, configDbDn, []string{}, false)
+}
+
+func replaceSyncrepl(conn *ldap.Conn, databaseDn string, values []string, mirror bool) error {
+	mirrorMode := ldap.NewModifyRequest(databaseDn, nil)
+	mirrorMode.Replace("olcMirrorMode", []string{strings.ToUpper(fmt.Sprint(mirror))})
+
+	syncrepl := ldap.NewModifyRequest(databaseDn, nil)
+	syncrepl.Replace("olcSyncrepl", values)
+
+	// mirror mode requires syncrepl to be configured before and removed after
+	requests := []*ldap.ModifyRequest{syncrepl, mirrorMode}
+	if !mirror {
+		requests = []*ldap.ModifyRequest{mirrorMode, syncrepl}
+	}
+
+	for _, request := range requests {
+		if err := conn.Modify(request); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func ensureModules(conn *ldap.Conn, modules []string) error {
+	result, err := search(conn, configBase, ldap.ScopeSingleLevel, "(objectClass=olcModuleList)", "olcModuleLoad")
+	if err != nil {
+		return err
+	}
+
+	if len(result.Entries) == 0 {
+		request := ldap.NewAddRequest("cn=module,cn=config", nil)
+		request.Attribute("objectClass", []string{"olcModuleList"})
+		request.Attribute("cn", []string{"module"})
+		request.Attribute("olcModulePath", []string{modulePath})
+		request.Attribute("olcModuleLoad", modules)
+
+		return conn.Add(request)
+	}
+
+	loaded := map[string]bool{}
+	for _, entry := range result.Entries {
+		for _, module := range entry.GetAttributeValues("olcModuleLoad") {
+			loaded[moduleName(module)] = true
+		}
+	}
+
+	missing := []string{}
+	for _, module := range modules {
+		if !loaded[moduleName(module)] {
+			missing = append(missing, module)
+		}
+	}
+
+	if len(missing) == 0 {
+		return nil
+	}
+
+	request := ldap.NewModifyRequest(result.Entries[0].DN, nil)
+	request.Add("olcModuleLoad", missing)
+
+	return conn.Modify(request)
+}
+
+// ensureAccesslog adds the cn=accesslog database recording the successful
+// writes of the replicated database, which delta-syncrepl consumes from.
+func ensureAccesslog(conn *ldap.Conn, config PodConfig) error {
+	result, err := search(conn, configBase, ldap.ScopeSingleLevel, "(olcSuffix=cn=accesslog)")
+	if err != nil {
+		return err
+	}
+
+	if len(result.Entries) == 0 {
+		request := ldap.NewAddRequest("olcDatabase=mdb,cn=config", nil)
+		request.Attribute("objectClass", []string{"olcDatabaseConfig", "olcMdbConfig"})
+		request.Attribute("olcDatabase", []string{"mdb"})
+		request.Attribute("olcSuffix", []string{"cn=accesslog"})
+		request.Attribute("olcDbDirectory", []string{config.AccesslogPath})
+		request.Attribute("olcRootDN", []string{config.AccesslogRoot})
+		request.Attribute("olcDbIndex", []string{"default eq", "entryCSN,objectClass,reqEnd,reqResult,reqStart,reqDN"})
+
+		if err = conn.Add(request); err != nil {
+			return err
+		}
+
+		if result, err = search(conn, configBase, ldap.ScopeSingleLevel, "(olcSuffix=cn=accesslog)"); err != nil {
+			return err
+		}
+		if len(result.Entries) == 0 {
+			return fmt.Errorf("database of cn=accesslog not found after created")
+		}
+	}
+
+	if err = ensureOverlay(conn, result.Entries[0].DN, "syncprov", "olcSyncProvConfig", map[string][]string{
+		"olcSpNoPresent":  {"TRUE"},
+		"olcSpReloadHint": {"TRUE"},
+	}); err != nil {
+		return err
+	}
+
+	return ensureOverlay(conn, config.DatabaseDn, "accesslog", "olcAccessLogConfig", map[string][]string{
+		"olcAccessLogDB":      {"cn=accesslog"},
+		"olcAccessLogOps":     {"writes"},
+		"olcAccessLogSuccess": {"TRUE"},
+		"olcAccessLogPurge":   {"07+00:00 01+00:00"},
+	})
+}
+
+func ensureOverlay(
+	conn *ldap.Conn,
+	databaseDn string,
+	overlay string,
+	objectClass string,
+	attributes map[string][]string,
+) error {
+	result, err := search(conn, databaseDn, ldap.ScopeSingleLevel, fmt.Sprintf("(objectClass=%s)", objectClass))
+	if err != nil {
+		return err
+	}
+
+	if len(result.Entries) > 0 {
+		return nil
+	}
+
+	request := ldap.NewAddRequest(fmt.Sprintf("olcOverlay=%s,%s", overlay, databaseDn), nil)
+	request.Attribute("objectClass", []string{"olcOverlayConfig", objectClass})
+	request.Attribute("olcOverlay", []string{overlay})
+	for name, values := range attributes {
+		request.Attribute(name, values)
+	}
+
+	return conn.Add(request)
+}
+
+func search(conn *ldap.Conn, base string, scope int, filter string, attributes ...string) (*ldap.SearchResult, error) {
+	if len(attributes) == 0 {
+		attributes = []string{"dn"}
+	}
+
+	return conn.Search(ldap.NewSearchRequest(
+		base,
+		scope,
+		ldap.NeverDerefAliases,
+		0,
+		int(configTimeout.Seconds()),
+		false,
+		filter,
+		attributes,
+		nil,
+	))
+}
+
+// moduleName strips the ordering prefix and the extension of a module, e.g.
+// "{0}syncprov.so" returns "syncprov".
+func moduleName(module string) string {
+	if i := strings.Index(module, "}"); strings.HasPrefix(module, "{") && i > 0 {
+		module = module[i+1:]
+	}
+
+	return strings.TrimSuffix(path.Base(module), path.Ext(module))
+}
@@ -0,0 +1,14 @@
+package replication
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestReplication(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	RunSpecs(t, "Replication Suite")
+}
@@ -0,0 +1,192 @@
+package replication
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	openldapv1 "github.com/qwp0905/openldap-operator/api/v1"
+)
+
+const (
+	accesslogFilter = "(&(objectClass=auditWriteObject)(reqResult=0))"
+
+	// configRid keeps the rid of the cn=config consumer apart from the ones of
+	// the replicated database.
+	configRid = 100
+
+	// configExcludedAttrs differ between the pods and are never replicated
+	// with cn=config.
+	configExcludedAttrs = "olcSyncrepl,olcMirrorMode"
+)
+
+var quoteEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// Provider is a server a pod consumes from.
+type Provider struct {
+	Url         string
+	BindDn      string
+	Credentials string
+	CaFile      string
+	StartTls    bool
+}
+
+// PodConfig is the replication config of a single pod in cn=config.
+type PodConfig struct {
+	DatabaseDn    string
+	Syncrepl      []string
+	MirrorMode    bool
+	Accesslog     bool
+	AccesslogPath string
+	AccesslogRoot string
+	ServerIds     []string
+	// ConfigSeed adds the overlays of cn=config, so that other pods consume
+	// cn=config from it.
+	ConfigSeed bool
+	// ConfigSyncrepl consumes cn=config from the seed, the overlays of the pod
+	// are then only replicated.
+	ConfigSyncrepl []string
+}
+
+func CreatePodConfig(cluster *openldapv1.OpenldapCluster, providers []Provider, mirrorMode bool) PodConfig {
+	syncrepl := []string{}
+	for i, provider := range providers {
+		syncrepl = append(syncrepl, RenderSyncrepl(cluster, i+1, provider))
+	}
+
+	config := PodConfig{
+		DatabaseDn:    cluster.GetReplication().DatabaseDn,
+		Syncrepl:      syncrepl,
+		MirrorMode:    mirrorMode,
+		Accesslog:     cluster.DeltaSyncreplEnabled(),
+		AccesslogPath: cluster.AccesslogPath(),
+		AccesslogRoot: cluster.AdminDn(),
+	}
+
+	// slapd refuses mirror mode without server ids
+	if cluster.IsMultiProvider() {
+		config.setServerIds(cluster)
+	}
+
+	return config
+}
+
+// setServerIds sets the server ids of all pods on each pod, slapd picks its
+// own by the url.
+func (c *PodConfig) setServerIds(cluster *openldapv1.OpenldapCluster) {
+	c.ServerIds = []string{}
+	for i := 0; i < cluster.GetReplicas(); i++ {
+		c.ServerIds = append(c.ServerIds, fmt.Sprintf("%d %s", cluster.ServerId(i), cluster.PodUrl(i)))
+	}
+}
+
+// SetConfigReplication makes the pod the seed of cn=config without a seed,
+// the pod consumes cn=config from the seed otherwise.
+func (c *PodConfig) SetConfigReplication(cluster *openldapv1.OpenldapCluster, seed *Provider) {
+	c.setServerIds(cluster)
+
+	if seed == nil {
+		c.ConfigSeed = true
+		return
+	}
+
+	c.ConfigSyncrepl = []string{RenderConfigSyncrepl(cluster, configRid, *seed)}
+}
+
+// ConfigConsumer returns whether cn=config of the pod is replicated from the
+// seed.
+func (c PodConfig) ConfigConsumer() bool {
+	return len(c.ConfigSyncrepl) > 0
+}
+
+// Hash identifies the rendered config, so that it is only written again once
+// it changed.
+func (c PodConfig) Hash() string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%+v", c)))
+
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// RenderSyncrepl renders a single olcSyncrepl value consuming from the
+// provider, delta-syncrepl consumes the changes logged in cn=accesslog.
+func RenderSyncrepl(cluster *openldapv1.OpenldapCluster, rid int, provider Provider) string {
+	config := cluster.GetReplication()
+	options := []string{
+		fmt.Sprintf("rid=%03d", rid),
+		fmt.Sprintf("provider=%s", provider.Url),
+		"bindmethod=simple",
+		fmt.Sprintf(`binddn="%s"`, quoteEscaper.Replace(provider.BindDn)),
+		fmt.Sprintf(`credentials="%s"`, quoteEscaper.Replace(provider.Credentials)),
+		fmt.Sprintf(`searchbase="%s"`, quoteEscaper.Replace(cluster.ReplicationSearchBase())),
+		"scope=sub",
+		"type=refreshAndPersist",
+		fmt.Sprintf("interval=%s", config.Interval),
+		fmt.Sprintf(`retry="%s"`, config.Retry),
+		fmt.Sprintf("timeout=%d", config.Timeout),
+		fmt.Sprintf("keepalive=%s", config.Keepalive),
+	}
+
+	if config.Filter != "" {
+		options = append(options, fmt.Sprintf(`filter="%s"`, quoteEscaper.Replace(config.Filter)))
+	}
+
+	if len(config.Attrs) > 0 {
+		options = append(options, fmt.Sprintf(`attrs="%s"`, strings.Join(config.Attrs, ",")))
+	}
+
+	options = append(options, tlsOptions(provider)...)
+
+	if cluster.DeltaSyncreplEnabled() {
+		options = append(
+			options,
+			`logbase="cn=accesslog"`,
+			fmt.Sprintf(`logfilter="%s"`, accesslogFilter),
+			"syncdata=accesslog",
+		)
+	}
+
+	return strings.Join(options, " ")
+}
+
+// RenderConfigSyncrepl renders a single olcSyncrepl value of the config
+// database consuming cn=config from the provider.
+func RenderConfigSyncrepl(cluster *openldapv1.OpenldapCluster, rid int, provider Provider) string {
+	config := cluster.GetReplication()
+	options := []string{
+		fmt.Sprintf("rid=%03d", rid),
+		fmt.Sprintf("provider=%s", provider.Url),
+		"bindmethod=simple",
+		fmt.Sprintf(`binddn="%s"`, quoteEscaper.Replace(provider.BindDn)),
+		fmt.Sprintf(`credentials="%s"`, quoteEscaper.Replace(provider.Credentials)),
+		`searchbase="cn=config"`,
+		"type=refreshAndPersist",
+		fmt.Sprintf(`retry="%s"`, config.Retry),
+		fmt.Sprintf("timeout=%d", config.Timeout),
+		fmt.Sprintf("keepalive=%s", config.Keepalive),
+		fmt.Sprintf(`exattrs="%s"`, configExcludedAttrs),
+	}
+
+	options = append(options, tlsOptions(provider)...)
+
+	return strings.Join(options, " ")
+}
+
+// tlsOptions verifies the provider with the ca, a plain connection is upgraded
+// with StartTLS when the provider requires tls.
+func tlsOptions(provider Provider) []string {
+	if provider.CaFile == "" {
+		return nil
+	}
+
+	options := []string{}
+	if provider.StartTls {
+		options = append(options, "starttls=critical")
+	}
+
+	return append(
+		options,
+		fmt.Sprintf(`tls_cacert="%s"`, provider.CaFile),
+		"tls_reqcert=demand",
+	)
+}
@@ -0,0 +1,142 @@
+package replication
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	openldapv1 "github.com/qwp0905/openldap-operator/api/v1"
+	"github.com/qwp0905/openldap-operator/internal/fixtures"
+)
+
+var _ = Describe("Syncrepl", func() {
+	provider := Provider{
+		Url:         "ldap://ldap-1.ldap-headless.default.svc.cluster.local:1389",
+		BindDn:      "cn=admin,dc=example,dc=com",
+		Credentials: `pa"ss`,
+	}
+
+	It("sets the server ids of every pod with multiple providers", func() {
+		for _, mode := range []openldapv1.TopologyMode{
+			openldapv1.TopologyMirrorMode,
+			openldapv1.TopologyMultiProvider,
+		} {
+			cluster := fixtures.WithTopology(fixtures.NewCluster("ldap", 3), mode)
+			config := CreatePodConfig(cluster, []Provider{provider}, true)
+
+			Expect(config.ServerIds).To(Equal([]string{
+				"1 " + cluster.PodUrl(0),
+				"2 " + cluster.PodUrl(1),
+				"3 " + cluster.PodUrl(2),
+			}))
+			Expect(config.DatabaseDn).To(Equal("olcDatabase={2}mdb,cn=config"))
+			Expect(config.Syncrepl).To(HaveLen(1))
+		}
+	})
+
+	It("sets no server ids with a single master", func() {
+		config := CreatePodConfig(fixtures.NewCluster("ldap", 3), []Provider{}, false)
+
+		Expect(config.ServerIds).To(BeNil())
+	})
+
+	It("renders the provider with quoted credentials", func() {
+		value := RenderSyncrepl(fixtures.NewCluster("ldap", 3), 2, provider)
+
+		Expect(value).To(HavePrefix("rid=002 provider=" + provider.Url + " "))
+		Expect(value).To(ContainSubstring(`credentials="pa\"ss"`))
+		Expect(value).To(ContainSubstring(`searchbase="dc=example,dc=com"`))
+		Expect(value).NotTo(ContainSubstring("tls_"))
+	})
+
+	It("verifies the provider and upgrades with StartTLS", func() {
+		cluster := fixtures.NewCluster("ldap", 3)
+		tlsProvider := provider
+		tlsProvider.CaFile = "/opt/bitnami/openldap/certs/ca.crt"
+
+		value := RenderSyncrepl(cluster, 1, tlsProvider)
+		Expect(value).To(HaveSuffix(`tls_cacert="/opt/bitnami/openldap/certs/ca.crt" tls_reqcert=demand`))
+		Expect(value).NotTo(ContainSubstring("starttls"))
+
+		tlsProvider.StartTls = true
+		Expect(RenderSyncrepl(cluster, 1, tlsProvider)).To(ContainSubstring("starttls=critical tls_cacert="))
+	})
+
+	It("changes the hash with the config", func() {
+		cluster := fixtures.WithTopology(fixtures.NewCluster("ldap", 3), openldapv1.TopologyMultiProvider)
+		config := CreatePodConfig(cluster, []Provider{provider}, true)
+
+		Expect(config.Hash()).To(Equal(CreatePodConfig(cluster, []Provider{provider}, true).Hash()))
+		Expect(config.Hash()).NotTo(Equal(CreatePodConfig(cluster, []Provider{}, true).Hash()))
+	})
+
+	It("strips the order and extension of modules", func() {
+		Expect(moduleName("{0}syncprov.so")).To(Equal("syncprov"))
+		Expect(moduleName("/usr/lib/openldap/accesslog.la")).To(Equal("accesslog"))
+	})
+
+	It("renders the options of the replication", func() {
+		cluster := fixtures.NewCluster("ldap", 3)
+		cluster.Spec.Replication.Interval = "00:01:00:00"
+		cluster.Spec.Replication.Retry = "60 10 300 +"
+		cluster.Spec.Replication.Timeout = 30
+		cluster.Spec.Replication.Keepalive = "120:5:10"
+		cluster.Spec.Replication.SearchBase = "ou=people,dc=example,dc=com"
+		cluster.Spec.Replication.Filter = "(objectClass=person)"
+		cluster.Spec.Replication.Attrs = []string{"cn", "mail"}
+
+		value := RenderSyncrepl(cluster, 1, provider)
+		Expect(value).To(ContainSubstring(`searchbase="ou=people,dc=example,dc=com"`))
+		Expect(value).To(ContainSubstring(`interval=00:01:00:00 retry="60 10 300 +" timeout=30 keepalive=120:5:10`))
+		Expect(value).To(ContainSubstring(`filter="(objectClass=person)" attrs="cn,mail"`))
+		Expect(value).NotTo(ContainSubstring("logbase"))
+	})
+
+	It("replicates the search base of the source of a replica cluster", func() {
+		cluster := fixtures.WithReplica(fixtures.NewCluster("ldap", 3), "ldap://ldap.example.com")
+		cluster.Spec.Replica.Source.SearchBase = "ou=groups,dc=example,dc=com"
+
+		Expect(RenderSyncrepl(cluster, 1, provider)).To(ContainSubstring(`searchbase="ou=groups,dc=example,dc=com"`))
+	})
+
+	It("consumes the accesslog with delta-syncrepl", func() {
+		cluster := fixtures.NewCluster("ldap", 3)
+		cluster.Spec.Replication.Enabled = true
+		cluster.Spec.Replication.Mode = openldapv1.ReplicationDeltaSyncrepl
+
+		Expect(RenderSyncrepl(cluster, 1, provider)).To(HaveSuffix(
+			`logbase="cn=accesslog" logfilter="(&(objectClass=auditWriteObject)(reqResult=0))" syncdata=accesslog`,
+		))
+
+		config := CreatePodConfig(cluster, []Provider{provider}, false)
+		Expect(config.Accesslog).To(BeTrue())
+		Expect(config.AccesslogPath).To(Equal(cluster.AccesslogPath()))
+		Expect(config.AccesslogRoot).To(Equal(cluster.AdminDn()))
+	})
+
+	It("seeds cn=config from a single pod", func() {
+		cluster := fixtures.NewCluster("ldap", 3)
+		cluster.Spec.Replication.Enabled = true
+		cluster.Spec.Replication.ConfigDatabase = true
+
+		seed := CreatePodConfig(cluster, []Provider{}, false)
+		seed.SetConfigReplication(cluster, nil)
+		Expect(seed.ConfigSeed).To(BeTrue())
+		Expect(seed.ConfigConsumer()).To(BeFalse())
+		Expect(seed.ServerIds).To(HaveLen(3))
+
+		consumer := CreatePodConfig(cluster, []Provider{provider}, false)
+		consumer.SetConfigReplication(cluster, &Provider{
+			Url:      cluster.PodUrl(0),
+			BindDn:   cluster.ConfigAdminDn(),
+			CaFile:   "/opt/bitnami/openldap/certs/ca.crt",
+			StartTls: true,
+		})
+		Expect(consumer.ConfigSeed).To(BeFalse())
+		Expect(consumer.ConfigConsumer()).To(BeTrue())
+		Expect(consumer.ConfigSyncrepl).To(HaveLen(1))
+		Expect(consumer.ConfigSyncrepl[0]).To(HavePrefix("rid=100 provider=" + cluster.PodUrl(0)))
+		Expect(consumer.ConfigSyncrepl[0]).To(ContainSubstring(`searchbase="cn=config"`))
+		Expect(consumer.ConfigSyncrepl[0]).To(ContainSubstring(`exattrs="olcSyncrepl,olcMirrorMode"`))
+		Expect(consumer.ConfigSyncrepl[0]).To(ContainSubstring("starttls=critical"))
+	})
+})
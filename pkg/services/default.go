@@ -0,0 +1,69 @@
+package services
+
+import (
+	openldapv1 "github.com/qwp0905/openldap-operator/api/v1"
+	"github.com/qwp0905/openldap-operator/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func ldapServicePorts(cluster *openldapv1.OpenldapCluster) []corev1.ServicePort {
+	ports := []corev1.ServicePort{}
+
+	if !cluster.LdapsOnly() {
+		ports = append(ports, corev1.ServicePort{
+			Name:     "ldap",
+			Port:     cluster.LdapPort(),
+			Protocol: corev1.ProtocolTCP,
+			TargetPort: intstr.IntOrString{
+				Type:   intstr.Int,
+				IntVal: cluster.LdapPort(),
+			},
+		})
+	}
+
+	if cluster.TlsEnabled() {
+		ports = append(ports, corev1.ServicePort{
+			Name:     "ldaps",
+			Port:     cluster.LdapsPort(),
+			Protocol: corev1.ProtocolTCP,
+			TargetPort: intstr.IntOrString{
+				Type:   intstr.Int,
+				IntVal: cluster.LdapsPort(),
+			},
+		})
+	}
+
+	return ports
+}
+
+func applyServiceTemplate(
+	service *corev1.Service,
+	template openldapv1.ServiceTemplate,
+) *corev1.Service {
+	service.SetLabels(utils.MergeMap(service.GetLabels(), template.Labels))
+	service.SetAnnotations(utils.MergeMap(service.GetAnnotations(), template.Annotations))
+	service.Spec.Type = template.Type
+
+	if template.Type == corev1.ServiceTypeClusterIP {
+		return service
+	}
+
+	service.Spec.ExternalTrafficPolicy = template.ExternalTrafficPolicy
+	if service.Spec.ExternalTrafficPolicy == "" {
+		service.Spec.ExternalTrafficPolicy = corev1.ServiceExternalTrafficPolicyTypeCluster
+	}
+
+	for i, port := range service.Spec.Ports {
+		if nodePort, ok := template.NodePorts[port.Name]; ok {
+			service.Spec.Ports[i].NodePort = nodePort
+		}
+	}
+
+	if template.Type == corev1.ServiceTypeLoadBalancer {
+		service.Spec.LoadBalancerClass = template.LoadBalancerClass
+		service.Spec.LoadBalancerSourceRanges = template.LoadBalancerSourceRanges
+	}
+
+	return service
+}
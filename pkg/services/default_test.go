@@ -0,0 +1,83 @@
+package services
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+
+	openldapv1 "github.com/qwp0905/openldap-operator/api/v1"
+	"github.com/qwp0905/openldap-operator/internal/fixtures"
+)
+
+var _ = Describe("Service templates", func() {
+	It("keeps services internal by default", func() {
+		cluster := fixtures.NewCluster("ldap", 3)
+
+		for _, service := range []*corev1.Service{
+			CreateReadService(cluster, false),
+			CreateWriteService(cluster),
+			CreateMetricsService(cluster),
+		} {
+			Expect(service.Spec.Type).To(Equal(corev1.ServiceTypeClusterIP))
+			Expect(service.Spec.ExternalTrafficPolicy).To(BeEmpty())
+		}
+	})
+
+	It("exposes the write service with its own labels and annotations", func() {
+		cluster := fixtures.NewCluster("ldap", 3)
+		lbClass := "service.k8s.aws/nlb"
+		cluster.Spec.Services.Write = &openldapv1.ServiceTemplate{
+			Type:                     corev1.ServiceTypeLoadBalancer,
+			Labels:                   map[string]string{"team": "directory"},
+			Annotations:              map[string]string{"external-dns.alpha.kubernetes.io/hostname": "ldap.example.com"},
+			LoadBalancerClass:        &lbClass,
+			LoadBalancerSourceRanges: []string{"10.0.0.0/8"},
+			NodePorts:                map[string]int32{"ldap": 30389},
+		}
+		service := CreateWriteService(cluster)
+
+		Expect(service.Spec.Type).To(Equal(corev1.ServiceTypeLoadBalancer))
+		Expect(service.Labels).To(HaveKeyWithValue("team", "directory"))
+		Expect(service.Annotations).To(HaveKey("external-dns.alpha.kubernetes.io/hostname"))
+		Expect(service.Spec.LoadBalancerClass).To(Equal(&lbClass))
+		Expect(service.Spec.LoadBalancerSourceRanges).To(ConsistOf("10.0.0.0/8"))
+		Expect(service.Spec.ExternalTrafficPolicy).To(Equal(corev1.ServiceExternalTrafficPolicyTypeCluster))
+		Expect(service.Spec.Ports[0].NodePort).To(Equal(int32(30389)))
+	})
+
+	It("drops the plain port if only ldaps is served", func() {
+		cluster := fixtures.WithTls(fixtures.NewCluster("ldap", 3))
+		cluster.Spec.OpenldapConfig.Tls.LdapsOnly = true
+		service := CreateReadService(cluster, false)
+
+		Expect(service.Spec.Ports).To(HaveLen(1))
+		Expect(service.Spec.Ports[0].Name).To(Equal("ldaps"))
+	})
+})
+
+var _ = Describe("Read service", func() {
+	It("routes reads to the slaves only once one is ready", func() {
+		cluster := fixtures.NewCluster("ldap", 3)
+		cluster.Spec.Services.Read.ExcludeMaster = true
+
+		Expect(CreateReadService(cluster, false).Spec.Selector).To(Equal(cluster.SelectorLabels()))
+		Expect(CreateReadService(cluster, true).Spec.Selector).To(Equal(cluster.SlaveSelectorLabels()))
+	})
+
+	It("includes the master unless excluded", func() {
+		cluster := fixtures.NewCluster("ldap", 3)
+
+		Expect(CreateReadService(cluster, true).Spec.Selector).To(Equal(cluster.SelectorLabels()))
+	})
+
+	It("writes to every provider of a multi-provider cluster", func() {
+		cluster := fixtures.NewCluster("ldap", 3)
+		Expect(CreateWriteService(cluster).Spec.Selector).To(Equal(cluster.MasterSelectorLabels()))
+
+		fixtures.WithTopology(cluster, openldapv1.TopologyMirrorMode)
+		Expect(CreateWriteService(cluster).Spec.Selector).To(Equal(cluster.MasterSelectorLabels()))
+
+		fixtures.WithTopology(cluster, openldapv1.TopologyMultiProvider)
+		Expect(CreateWriteService(cluster).Spec.Selector).To(Equal(cluster.SelectorLabels()))
+	})
+})
@@ -0,0 +1,25 @@
+package services
+
+import (
+	openldapv1 "github.com/qwp0905/openldap-operator/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func CreateHeadlessService(cluster *openldapv1.OpenldapCluster) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        cluster.HeadlessServiceName(),
+			Namespace:   cluster.Namespace,
+			Labels:      cluster.DefaultLabels(),
+			Annotations: cluster.GetAnnotations(),
+		},
+		Spec: corev1.ServiceSpec{
+			Type:                     corev1.ServiceTypeClusterIP,
+			ClusterIP:                corev1.ClusterIPNone,
+			PublishNotReadyAddresses: true,
+			Ports:                    ldapServicePorts(cluster),
+			Selector:                 cluster.SelectorLabels(),
+		},
+	}
+}
@@ -0,0 +1,22 @@
+package services
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/qwp0905/openldap-operator/internal/fixtures"
+)
+
+var _ = Describe("Headless service", func() {
+	It("publishes every pod before it is ready", func() {
+		cluster := fixtures.NewCluster("ldap", 3)
+		service := CreateHeadlessService(cluster)
+
+		Expect(service.Name).To(Equal("ldap-headless"))
+		Expect(service.Spec.ClusterIP).To(Equal(corev1.ClusterIPNone))
+		Expect(service.Spec.PublishNotReadyAddresses).To(BeTrue())
+		Expect(service.Spec.Selector).To(Equal(cluster.SelectorLabels()))
+		Expect(cluster.PodDnsName(1)).To(Equal("ldap-1.ldap-headless.default.svc.cluster.local"))
+	})
+})
@@ -8,7 +8,7 @@ import (
 )
 
 func CreateMetricsService(cluster *openldapv1.OpenldapCluster) *corev1.Service {
-	return &corev1.Service{
+	service := &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:        cluster.MetricsServiceName(),
 			Namespace:   cluster.Namespace,
@@ -31,4 +31,6 @@ func CreateMetricsService(cluster *openldapv1.OpenldapCluster) *corev1.Service {
 			Selector: cluster.SelectorLabels(),
 		},
 	}
+
+	return applyServiceTemplate(service, cluster.MetricsServiceTemplate())
 }
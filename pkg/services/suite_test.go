@@ -0,0 +1,14 @@
+package services
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestServices(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	RunSpecs(t, "Services Suite")
+}
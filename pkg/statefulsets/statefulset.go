@@ -22,11 +22,17 @@ func CreateStatefulset(cluster *openldapv1.OpenldapCluster) *appsv1.StatefulSet
 		volumes = append(volumes, pods.SeedVolumes(cluster))
 	}
 
+	if cluster.ReplicaEnabled() && cluster.GetReplicaSource().Ca != nil {
+		volumeMounts = append(volumeMounts, pods.ReplicaCaVolumeMount(cluster))
+		initVolumeMounts = append(initVolumeMounts, pods.ReplicaCaVolumeMount(cluster))
+		volumes = append(volumes, pods.ReplicaCaVolume(cluster))
+	}
+
 	initContainers := []corev1.Container{{
 		Name:            cluster.InitContainerName(),
 		Image:           template.Image,
 		ImagePullPolicy: template.ImagePullPolicy,
-		Command:         []string{"/opt/bitnami/scripts/openldap/setup.sh"},
+		Command:         pods.InitCommand(cluster),
 		Resources:       template.Resources,
 		Env:             pods.DefaultEnvs(cluster),
 		VolumeMounts:    initVolumeMounts,
@@ -46,7 +52,7 @@ func CreateStatefulset(cluster *openldapv1.OpenldapCluster) *appsv1.StatefulSet
 					TCPSocket: &corev1.TCPSocketAction{
 						Port: intstr.IntOrString{
 							Type:   intstr.Int,
-							IntVal: cluster.LdapPort(),
+							IntVal: cluster.ServingPort(),
 						},
 					},
 				},
@@ -61,7 +67,7 @@ func CreateStatefulset(cluster *openldapv1.OpenldapCluster) *appsv1.StatefulSet
 					TCPSocket: &corev1.TCPSocketAction{
 						Port: intstr.IntOrString{
 							Type:   intstr.Int,
-							IntVal: cluster.LdapPort(),
+							IntVal: cluster.ServingPort(),
 						},
 					},
 				},
@@ -77,10 +83,14 @@ func CreateStatefulset(cluster *openldapv1.OpenldapCluster) *appsv1.StatefulSet
 		},
 	}
 
-	if cluster.MonitorEnabled() {
+	if cluster.ExporterEnabled() {
 		containers = append(containers, pods.CreateExporterContainer(cluster))
 	}
 
+	if cluster.FencingEnabled() {
+		containers = append(containers, pods.CreateFencerContainer(cluster))
+	}
+
 	podSpec := &corev1.PodSpec{
 		InitContainers:                initContainers,
 		Containers:                    containers,
@@ -99,6 +109,14 @@ func CreateStatefulset(cluster *openldapv1.OpenldapCluster) *appsv1.StatefulSet
 		podSpec.PriorityClassName = template.PriorityClassName
 	}
 
+	if cluster.FencingEnabled() {
+		podSpec.ServiceAccountName = cluster.Name
+	}
+
+	if template.TopologySpreadConstraints != nil {
+		podSpec.TopologySpreadConstraints = template.TopologySpreadConstraints
+	}
+
 	return &appsv1.StatefulSet{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:        cluster.Name,
@@ -110,7 +128,8 @@ func CreateStatefulset(cluster *openldapv1.OpenldapCluster) *appsv1.StatefulSet
 			Selector: &metav1.LabelSelector{
 				MatchLabels: cluster.SelectorLabels(),
 			},
-			Replicas: &cluster.Spec.Replicas,
+			Replicas:    &cluster.Spec.Replicas,
+			ServiceName: cluster.HeadlessServiceName(),
 			UpdateStrategy: appsv1.StatefulSetUpdateStrategy{
 				Type: appsv1.RollingUpdateStatefulSetStrategyType,
 				RollingUpdate: &appsv1.RollingUpdateStatefulSetStrategy{
@@ -120,11 +139,8 @@ func CreateStatefulset(cluster *openldapv1.OpenldapCluster) *appsv1.StatefulSet
 					},
 				},
 			},
-			PersistentVolumeClaimRetentionPolicy: &appsv1.StatefulSetPersistentVolumeClaimRetentionPolicy{
-				WhenDeleted: appsv1.RetainPersistentVolumeClaimRetentionPolicyType,
-				WhenScaled:  appsv1.DeletePersistentVolumeClaimRetentionPolicyType,
-			},
-			MinReadySeconds: 0,
+			PersistentVolumeClaimRetentionPolicy: cluster.GetRetentionPolicy(),
+			MinReadySeconds:                      0,
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
 					Labels:      cluster.GetSlaveLabels(),
@@ -146,6 +162,16 @@ func CreateStatefulset(cluster *openldapv1.OpenldapCluster) *appsv1.StatefulSet
 	}
 }
 
+func GetInitContainer(st *appsv1.StatefulSet, name string) *corev1.Container {
+	for _, c := range st.Spec.Template.Spec.InitContainers {
+		if c.Name == name {
+			return &c
+		}
+	}
+
+	return nil
+}
+
 func GetContainer(st *appsv1.StatefulSet, name string) *corev1.Container {
 	for _, c := range st.Spec.Template.Spec.Containers {
 		if c.Name == name {
@@ -0,0 +1,30 @@
+package statefulsets
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/equality"
+
+	openldapv1 "github.com/qwp0905/openldap-operator/api/v1"
+	"github.com/qwp0905/openldap-operator/internal/fixtures"
+)
+
+var _ = Describe("Statefulset", func() {
+	DescribeTable("keeps the pod template on scaling, so that no pod is restarted",
+		func(mode openldapv1.TopologyMode) {
+			cluster := fixtures.WithTopology(fixtures.NewCluster("ldap", 3), mode)
+			cluster.Spec.Replication.Enabled = mode == openldapv1.TopologyMultiProvider
+
+			scaled := cluster.DeepCopy()
+			scaled.Spec.Replicas = 5
+
+			Expect(equality.Semantic.DeepEqual(
+				CreateStatefulset(cluster).Spec.Template,
+				CreateStatefulset(scaled).Spec.Template,
+			)).To(BeTrue())
+		},
+		Entry("single master", openldapv1.TopologySingleMaster),
+		Entry("mirror mode", openldapv1.TopologyMirrorMode),
+		Entry("multi-provider", openldapv1.TopologyMultiProvider),
+	)
+})
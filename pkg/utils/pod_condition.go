@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"time"
+
 	corev1 "k8s.io/api/core/v1"
 )
 
@@ -24,6 +26,17 @@ func IsPodReady(pod corev1.Pod) bool {
 	return false
 }
 
+// IsPodReadyFor check if a Pod has been ready for at least the duration
+func IsPodReadyFor(pod corev1.Pod, duration time.Duration) bool {
+	for _, c := range pod.Status.Conditions {
+		if c.Type == corev1.ContainersReady && c.Status == corev1.ConditionTrue {
+			return time.Since(c.LastTransitionTime.Time) >= duration
+		}
+	}
+
+	return false
+}
+
 // IsPodActive checks if a pod is active, copied from:
 // https://github.com/kubernetes/kubernetes/blob/1bd0077/test/e2e/framework/pod/resource.go#L664
 func IsPodActive(p corev1.Pod) bool {